@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+)
+
+// CalVer is a format of the Calendar Versioning (e.g. "YYYY.MM.MICRO" or "YY.0M.0D.N").
+// Date segments (YYYY, YY, 0Y, MM, 0M, DD and 0D) come first, and counters (MAJOR, MINOR, MICRO and N) follow them.
+// SPEC: https://calver.org/
+type CalVer struct {
+	format   string
+	segments []string
+	// dates is the number of the date segments.
+	dates int
+}
+
+func isCalVerDate(seg string) bool {
+	switch seg {
+	case "YYYY", "YY", "0Y", "MM", "0M", "DD", "0D":
+		return true
+	}
+	return false
+}
+
+func isCalVerCounter(seg string) bool {
+	switch seg {
+	case "MAJOR", "MINOR", "MICRO", "N":
+		return true
+	}
+	return false
+}
+
+func ParseCalVer(format string) (*CalVer, error) {
+	c := &CalVer{format: format, segments: strings.Split(format, ".")}
+	seen := map[string]bool{}
+	for i, seg := range c.segments {
+		switch {
+		case seen[seg]:
+			return nil, fmt.Errorf("invalid CalVer format %q: %s is duplicated", format, seg)
+		case isCalVerDate(seg):
+			if i > c.dates {
+				return nil, fmt.Errorf("invalid CalVer format %q: %s should be put before the counters", format, seg)
+			}
+			c.dates++
+		case isCalVerCounter(seg):
+		default:
+			return nil, fmt.Errorf("invalid CalVer format %q: unknown segment %q", format, seg)
+		}
+		seen[seg] = true
+	}
+	if c.dates == 0 {
+		return nil, fmt.Errorf("invalid CalVer format %q: it should have a date segment like YYYY", format)
+	}
+	return c, nil
+}
+
+func (c *CalVer) String() string {
+	return c.format
+}
+
+// Zero is the version before the first one.
+func (c *CalVer) Zero() Ver {
+	return calVersion{format: c, values: make([]uint64, len(c.segments))}
+}
+
+// Parse parses the version in the format.
+func (c *CalVer) Parse(s string) (Ver, bool) {
+	fields := strings.Split(s, ".")
+	if len(fields) != len(c.segments) {
+		return nil, false
+	}
+	v := calVersion{format: c, values: make([]uint64, len(fields))}
+	for i, f := range fields {
+		n, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		switch c.segments[i] {
+		case "MM", "0M":
+			if n < 1 || n > 12 {
+				return nil, false
+			}
+		case "DD", "0D":
+			if n < 1 || n > 31 {
+				return nil, false
+			}
+		}
+		v.values[i] = n
+	}
+	// reject the padding out of the format (e.g. "2024.05" for "YYYY.MM")
+	if v.String() != s {
+		return nil, false
+	}
+	return v, true
+}
+
+func calVerDate(seg string, t time.Time) uint64 {
+	switch seg {
+	case "YYYY":
+		return uint64(t.Year())
+	case "YY", "0Y":
+		return uint64(t.Year() - 2000)
+	case "MM", "0M":
+		return uint64(t.Month())
+	default:
+		return uint64(t.Day())
+	}
+}
+
+// counter finds the counter to increment for the level: MAJOR, MINOR or MICRO, or the last counter.
+func (c *CalVer) counter(level string) (int, error) {
+	var name string
+	switch level {
+	case "major":
+		name = "MAJOR"
+	case "minor":
+		name = "MINOR"
+	case "patch":
+		name = "MICRO"
+	default:
+		return 0, fmt.Errorf("%w: %s of CalVer", ErrUnsupported, level)
+	}
+	last := -1
+	for i := c.dates; i < len(c.segments); i++ {
+		if c.segments[i] == name {
+			return i, nil
+		}
+		last = i
+	}
+	return last, nil
+}
+
+// Bump computes the next version of cur at the date of the change.
+// A new date resets the counters, and the same date increments the counter of the level
+// ("major", "minor" or "patch") and resets the following ones.
+func (c *CalVer) Bump(cur Ver, ch Change) (Ver, error) {
+	if len(ch.Pre) > 0 || len(ch.Build) > 0 {
+		return nil, fmt.Errorf("%w: CalVer has no pre-release or build notation", ErrUnsupported)
+	}
+	i, err := c.counter(ch.Kind)
+	if err != nil {
+		return nil, err
+	}
+	prev, ok := cur.(calVersion)
+	if !ok {
+		prev = c.Zero().(calVersion)
+	}
+	next := calVersion{format: c, values: make([]uint64, len(c.segments))}
+	for j := 0; j < c.dates; j++ {
+		next.values[j] = calVerDate(c.segments[j], ch.Date)
+	}
+	switch compareRelease(next.values[:c.dates], prev.values[:c.dates]) {
+	case 1:
+		return next, nil
+	case -1:
+		return nil, fmt.Errorf("the date of %s is after %s", prev, ch.Date.Format(time.DateOnly))
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("%s is already tagged on %s: put a counter (e.g. MICRO) in the format %s", prev, ch.Date.Format(time.DateOnly), c)
+	}
+	copy(next.values[c.dates:i], prev.values[c.dates:i])
+	next.values[i] = prev.values[i] + 1
+	return next, nil
+}
+
+// calVersion is a version in a CalVer format: the values of the segments.
+type calVersion struct {
+	format *CalVer
+	values []uint64
+}
+
+func (v calVersion) String() string {
+	fields := make([]string, len(v.values))
+	for i, n := range v.values {
+		switch v.format.segments[i] {
+		case "0Y", "0M", "0D":
+			fields[i] = fmt.Sprintf("%02d", n)
+		default:
+			fields[i] = strconv.FormatUint(n, 10)
+		}
+	}
+	return strings.Join(fields, ".")
+}
+
+func (v calVersion) Compare(o Ver) int {
+	w, ok := o.(calVersion)
+	if !ok {
+		return v.Semver().Compare(o.Semver())
+	}
+	return compareRelease(v.values, w.values)
+}
+
+// Semver maps the first three segments to the major, minor and patch numbers.
+func (v calVersion) Semver() semver.Version {
+	return releaseSemver(v.values)
+}
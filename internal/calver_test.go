@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalVer(t *testing.T) {
+	t.Run("invalid format", func(t *testing.T) {
+		for _, format := range []string{"MICRO", "YYYY.MICRO.MM", "YYYY.YYYY", "YYYY.WW", ""} {
+			_, err := ParseCalVer(format)
+			assert.Error(t, err, format)
+		}
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		c, err := ParseCalVer("YY.0M.0D.N")
+		require.NoError(t, err)
+		v, ok := c.Parse("24.05.17.2")
+		require.True(t, ok)
+		assert.Equal(t, "24.05.17.2", v.String())
+		assert.Equal(t, semver.Version{Major: 24, Minor: 5, Patch: 17}, v.Semver())
+		for _, s := range []string{"24.5.17.2", "24.05.17", "24.13.01.0", "24.05.17.x", "24.05.17.02"} {
+			_, ok := c.Parse(s)
+			assert.False(t, ok, s)
+		}
+	})
+
+	t.Run("compare", func(t *testing.T) {
+		c, err := ParseCalVer("YYYY.MM.MICRO")
+		require.NoError(t, err)
+		a, _ := c.Parse("2024.9.10")
+		b, _ := c.Parse("2024.10.2")
+		assert.Equal(t, -1, a.Compare(b))
+		assert.Equal(t, 1, b.Compare(a))
+		assert.Equal(t, 0, a.Compare(a))
+		assert.Equal(t, -1, c.Zero().Compare(a))
+	})
+
+	t.Run("next", func(t *testing.T) {
+		c, err := ParseCalVer("YYYY.0M.MINOR.MICRO")
+		require.NoError(t, err)
+		today := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+		next := func(cur, level string) (string, error) {
+			t.Helper()
+			v := c.Zero()
+			if cur != "" {
+				var ok bool
+				v, ok = c.Parse(cur)
+				require.True(t, ok, cur)
+			}
+			n, err := c.Bump(v, Change{Kind: level, Date: today})
+			if err != nil {
+				return "", err
+			}
+			return n.String(), nil
+		}
+		for _, tc := range []struct{ cur, level, next string }{
+			{"", "patch", "2024.05.0.0"},
+			{"2024.04.3.2", "patch", "2024.05.0.0"},
+			{"2024.05.3.2", "patch", "2024.05.3.3"},
+			{"2024.05.3.2", "minor", "2024.05.4.0"},
+			{"2024.05.3.2", "major", "2024.05.3.3"}, // the last counter without MAJOR
+		} {
+			v, err := next(tc.cur, tc.level)
+			require.NoError(t, err, tc)
+			assert.Equal(t, tc.next, v, tc)
+		}
+
+		_, err = next("2024.06.0.0", "patch")
+		assert.ErrorContains(t, err, "after 2024-05-17")
+		_, err = next("2024.05.0.0", "pre")
+		assert.ErrorIs(t, err, ErrUnsupported)
+
+		daily, err := ParseCalVer("YYYY.0M.0D")
+		require.NoError(t, err)
+		cur, _ := daily.Parse("2024.05.17")
+		_, err = daily.Bump(cur, Change{Kind: "patch", Date: today})
+		assert.ErrorContains(t, err, "already tagged")
+	})
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type Changelog struct {
+	From   string           `json:"from,omitempty"`
+	To     string           `json:"to"`
+	Groups []ChangelogGroup `json:"groups"`
+}
+
+type ChangelogGroup struct {
+	Type    string           `json:"type"`
+	Title   string           `json:"title"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+type ChangelogEntry struct {
+	Hash        string `json:"hash"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description"`
+	Breaking    bool   `json:"breaking,omitempty"`
+}
+
+const (
+	changelogBreaking = "breaking"
+	changelogOther    = "other"
+)
+
+var changelogTitles = map[string]string{
+	changelogBreaking: "BREAKING CHANGES",
+	"feat":            "Features",
+	"fix":             "Bug Fixes",
+	"perf":            "Performance Improvements",
+	changelogOther:    "Other Changes",
+}
+
+// changelogOrder is the order of the groups; other types are put after them in alphabetical order.
+var changelogOrder = []string{changelogBreaking, "feat", "fix", "perf"}
+
+func changelogRank(typ string) int {
+	for i, t := range changelogOrder {
+		if t == typ {
+			return i
+		}
+	}
+	if typ == changelogOther {
+		return len(changelogOrder) + 1
+	}
+	return len(changelogOrder)
+}
+
+func NewChangelog(from, to string, commits []Commit) *Changelog {
+	groups := map[string]*ChangelogGroup{}
+	for _, commit := range commits {
+		entry := ChangelogEntry{Hash: commit.Hash}
+		typ := changelogOther
+		if c, ok := ParseConventionalCommit(commit.Message); ok {
+			entry.Scope = c.Scope
+			entry.Description = c.Description
+			entry.Breaking = c.Breaking
+			typ = c.Type
+			if c.Breaking {
+				typ = changelogBreaking
+			}
+		} else {
+			entry.Description = firstLine(commit.Message)
+		}
+		group, ok := groups[typ]
+		if !ok {
+			title, ok := changelogTitles[typ]
+			if !ok {
+				title = typ
+			}
+			group = &ChangelogGroup{Type: typ, Title: title}
+			groups[typ] = group
+		}
+		group.Entries = append(group.Entries, entry)
+	}
+
+	log := &Changelog{From: from, To: to, Groups: []ChangelogGroup{}}
+	for _, group := range groups {
+		log.Groups = append(log.Groups, *group)
+	}
+	sort.Slice(log.Groups, func(i, j int) bool {
+		ri, rj := changelogRank(log.Groups[i].Type), changelogRank(log.Groups[j].Type)
+		if ri != rj {
+			return ri < rj
+		}
+		return log.Groups[i].Type < log.Groups[j].Type
+	})
+	return log
+}
+
+func (c *Changelog) WriteMarkdown(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "## %s\n", c.To); err != nil {
+		return err
+	}
+	for _, group := range c.Groups {
+		if _, err := fmt.Fprintf(w, "\n### %s\n\n", group.Title); err != nil {
+			return err
+		}
+		for _, entry := range group.Entries {
+			scope := ""
+			if entry.Scope != "" {
+				scope = "**" + entry.Scope + ":** "
+			}
+			if _, err := fmt.Fprintf(w, "- %s%s (%s)\n", scope, entry.Description, shortHash(entry.Hash)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Changelog) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangelog(t *testing.T) {
+	log := NewChangelog("v1.0.0", "v1.1.0", []Commit{
+		{Hash: "1111111111", Message: "feat(tagger): add an option"},
+		{Hash: "2222222222", Message: "fix: typo"},
+		{Hash: "3333333333", Message: "Merge branch 'main'\n\nbody"},
+		{Hash: "4444444444", Message: "refactor!: drop a flag"},
+		{Hash: "5555555555", Message: "docs: readme"},
+		{Hash: "6666666666", Message: "feat: add a command"},
+	})
+	t.Run("groups", func(t *testing.T) {
+		var types []string
+		for _, g := range log.Groups {
+			types = append(types, g.Type)
+		}
+		assert.Equal(t, []string{"breaking", "feat", "fix", "docs", "other"}, types)
+	})
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, log.WriteMarkdown(&buf))
+		assert.Equal(t, `## v1.1.0
+
+### BREAKING CHANGES
+
+- drop a flag (4444444)
+
+### Features
+
+- **tagger:** add an option (1111111)
+- add a command (6666666)
+
+### Bug Fixes
+
+- typo (2222222)
+
+### docs
+
+- readme (5555555)
+
+### Other Changes
+
+- Merge branch 'main' (3333333)
+`, buf.String())
+	})
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, NewChangelog("", "v0.1.0", []Commit{{Hash: "1111111111", Message: "fix(foo): bar"}}).WriteJSON(&buf))
+		assert.JSONEq(t, `{
+			"to": "v0.1.0",
+			"groups": [{
+				"type": "fix",
+				"title": "Bug Fixes",
+				"entries": [{"hash": "1111111111", "scope": "foo", "description": "bar"}]
+			}]
+		}`, buf.String())
+	})
+}
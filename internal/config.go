@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfigFile is the name of the configuration file put at the root of the repository.
+const ConfigFile = ".git-vertag.toml"
+
+// Config holds the defaults of the repository.
+// Nil (or empty) fields are not configured.
+type Config struct {
+	Prefix *string `toml:"prefix"`
+	// Pattern is the pattern of tag names (e.g. "release-{{version}}"). It takes precedence over Prefix.
+	Pattern *string `toml:"pattern"`
+	// CalVer is the format of the Calendar Versioning (e.g. "YYYY.MM.MICRO"). See ParseCalVer.
+	CalVer *string `toml:"calver"`
+	// Scheme is the name of the version scheme (e.g. "pep440"). See Schemes.
+	Scheme *string `toml:"scheme"`
+	Fetch  *bool   `toml:"fetch"`
+	// FetchFrom are the remotes to fetch tags from.
+	FetchFrom []string `toml:"fetch-from"`
+	PruneTags *bool    `toml:"prune-tags"`
+	Ancestors *bool    `toml:"ancestors"`
+	PushTo    *string  `toml:"push-to"`
+	Message   []string `toml:"message"`
+	// MessageTemplate is a text/template for tag messages. See MessageData for the data.
+	MessageTemplate *string  `toml:"message-template"`
+	Pre             []string `toml:"pre"`
+	// Channels are the pre-release channels in order for "pre --promote".
+	Channels []string `toml:"channels"`
+	// Hooks are shell commands for each hook (e.g. "pre-tag").
+	Hooks map[string][]string `toml:"hooks"`
+	// Files are the files to sync the version with "--write-files".
+	Files []VersionFile `toml:"files"`
+}
+
+// LoadConfig loads the configuration file at the root of the repository and the "vertag" section in the git config.
+// The git config takes precedence over the file.
+func LoadConfig(t *Tagger) (*Config, error) {
+	var cfg Config
+	top, err := t.GetTopLevel()
+	if err != nil {
+		// not in a repository: nothing to load
+		return &cfg, nil
+	}
+	name := filepath.Join(top, ConfigFile)
+	if _, err := toml.DecodeFile(name, &cfg); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load %s: %w", name, err)
+	}
+	if err := cfg.loadGitConfig(t); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) loadGitConfig(t *Tagger) error {
+	var buf bytes.Buffer
+	// values are separated with NUL, since they may contain newlines (e.g. a message template)
+	if err := t.run(false, &buf, "config", "--null", "--get-regexp", `^vertag\.`); err != nil {
+		// no variable in the section
+		return nil
+	}
+	var message, pre, channels, fetchFrom []string
+	var files []VersionFile
+	hooks := map[string][]string{}
+	for _, entry := range strings.Split(buf.String(), "\x00") {
+		if entry == "" {
+			continue
+		}
+		// a key without value (e.g. "ancestors" for true) has no newline
+		key, value, _ := strings.Cut(entry, "\n")
+		switch key {
+		case "vertag.prefix":
+			c.Prefix = &value
+		case "vertag.pattern":
+			c.Pattern = &value
+		case "vertag.calver":
+			c.CalVer = &value
+		case "vertag.scheme":
+			c.Scheme = &value
+		case "vertag.fetch":
+			b, err := parseGitBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			c.Fetch = &b
+		case "vertag.fetch-from":
+			fetchFrom = append(fetchFrom, value)
+		case "vertag.prune-tags":
+			b, err := parseGitBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			c.PruneTags = &b
+		case "vertag.ancestors":
+			b, err := parseGitBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			c.Ancestors = &b
+		case "vertag.push-to":
+			c.PushTo = &value
+		case "vertag.message":
+			message = append(message, value)
+		case "vertag.message-template":
+			c.MessageTemplate = &value
+		case "vertag.pre":
+			pre = append(pre, value)
+		case "vertag.channels":
+			channels = append(channels, value)
+		case "vertag.files":
+			f, err := ParseVersionFile(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			files = append(files, f)
+		default:
+			if name := strings.TrimPrefix(key, "vertag.hooks."); name != key {
+				hooks[name] = append(hooks[name], value)
+			}
+		}
+	}
+	if len(message) > 0 {
+		c.Message = message
+	}
+	if len(pre) > 0 {
+		c.Pre = pre
+	}
+	if len(channels) > 0 {
+		c.Channels = channels
+	}
+	if len(fetchFrom) > 0 {
+		c.FetchFrom = fetchFrom
+	}
+	if len(files) > 0 {
+		c.Files = files
+	}
+	for name, commands := range hooks {
+		if c.Hooks == nil {
+			c.Hooks = map[string][]string{}
+		}
+		c.Hooks[name] = commands
+	}
+	return nil
+}
+
+func parseGitBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "", "yes", "on", "true", "1":
+		return true, nil
+	case "no", "off", "false", "0":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
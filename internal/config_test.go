@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tset := func(t *testing.T, file, gitConfig string) *Tagger {
+		t.Helper()
+		top := t.TempDir()
+		if file != "" {
+			require.NoError(t, os.WriteFile(filepath.Join(top, ConfigFile), []byte(file), 0644))
+		}
+		return &Tagger{Runner: &MockRunner{echo: &bytes.Buffer{}, outputs: map[string]io.Reader{
+			"rev-parse --show-toplevel":            strings.NewReader(top + "\n"),
+			`config --null --get-regexp ^vertag\.`: strings.NewReader(gitConfig),
+		}}}
+	}
+	t.Run("empty", func(t *testing.T) {
+		cfg, err := LoadConfig(tset(t, "", ""))
+		require.NoError(t, err)
+		assert.Equal(t, &Config{}, cfg)
+	})
+	t.Run("file", func(t *testing.T) {
+		cfg, err := LoadConfig(tset(t, `
+prefix = "release-"
+fetch = false
+ancestors = true
+push-to = "origin"
+message = ["foo", "bar"]
+message-template = "{{.Next}}"
+pre = ["alpha"]
+channels = ["alpha", "rc"]
+`, ""))
+		require.NoError(t, err)
+		require.NotNil(t, cfg.Prefix)
+		assert.Equal(t, "release-", *cfg.Prefix)
+		require.NotNil(t, cfg.Fetch)
+		assert.False(t, *cfg.Fetch)
+		require.NotNil(t, cfg.Ancestors)
+		assert.True(t, *cfg.Ancestors)
+		require.NotNil(t, cfg.PushTo)
+		assert.Equal(t, "origin", *cfg.PushTo)
+		assert.Equal(t, []string{"foo", "bar"}, cfg.Message)
+		require.NotNil(t, cfg.MessageTemplate)
+		assert.Equal(t, "{{.Next}}", *cfg.MessageTemplate)
+		assert.Equal(t, []string{"alpha"}, cfg.Pre)
+		assert.Equal(t, []string{"alpha", "rc"}, cfg.Channels)
+	})
+	t.Run("git config overrides file", func(t *testing.T) {
+		cfg, err := LoadConfig(tset(t, "prefix = \"release-\"\npre = [\"alpha\"]\n", "vertag.prefix\nv\x00vertag.fetch\nno\x00vertag.ancestors\x00vertag.pre\nbeta\x00vertag.pre\n1\x00vertag.channels\ndev\x00vertag.channels\nrc\x00"))
+		require.NoError(t, err)
+		require.NotNil(t, cfg.Prefix)
+		assert.Equal(t, "v", *cfg.Prefix)
+		require.NotNil(t, cfg.Fetch)
+		assert.False(t, *cfg.Fetch)
+		require.NotNil(t, cfg.Ancestors)
+		assert.True(t, *cfg.Ancestors)
+		assert.Nil(t, cfg.PushTo)
+		assert.Equal(t, []string{"beta", "1"}, cfg.Pre)
+		assert.Equal(t, []string{"dev", "rc"}, cfg.Channels)
+	})
+	t.Run("fetch", func(t *testing.T) {
+		cfg, err := LoadConfig(tset(t, "fetch-from = [\"origin\"]\nprune-tags = true\n", "vertag.fetch-from\norigin\x00vertag.fetch-from\nupstream\x00vertag.prune-tags\nno\x00"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"origin", "upstream"}, cfg.FetchFrom)
+		require.NotNil(t, cfg.PruneTags)
+		assert.False(t, *cfg.PruneTags)
+	})
+	t.Run("hooks", func(t *testing.T) {
+		cfg, err := LoadConfig(tset(t, `
+[hooks]
+pre-tag = ["go test ./..."]
+post-tag = ["echo file"]
+`, "vertag.hooks.post-tag\necho $GIT_VERTAG_NEXT\x00vertag.hooks.post-tag\nmake notify\x00"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{
+			"pre-tag":  {"go test ./..."},
+			"post-tag": {"echo $GIT_VERTAG_NEXT", "make notify"},
+		}, cfg.Hooks)
+	})
+	t.Run("files", func(t *testing.T) {
+		cfg, err := LoadConfig(tset(t, `
+[[files]]
+type = "json"
+path = "package.json"
+
+[[files]]
+type = "yaml"
+path = "Chart.yaml"
+key = "appVersion"
+`, ""))
+		require.NoError(t, err)
+		assert.Equal(t, []VersionFile{
+			{Type: VersionFileJSON, Path: "package.json"},
+			{Type: VersionFileYAML, Path: "Chart.yaml", Key: "appVersion"},
+		}, cfg.Files)
+
+		cfg, err = LoadConfig(tset(t, "", "vertag.files\ngo:version.go\x00"))
+		require.NoError(t, err)
+		assert.Equal(t, []VersionFile{{Type: VersionFileGo, Path: "version.go"}}, cfg.Files)
+
+		_, err = LoadConfig(tset(t, "", "vertag.files\ntoml:Cargo.toml\x00"))
+		assert.Error(t, err)
+	})
+	t.Run("multi-line value", func(t *testing.T) {
+		cfg, err := LoadConfig(tset(t, "", "vertag.message-template\n{{.Next}}\n\nby {{.Author}}\x00vertag.prefix\nv\x00"))
+		require.NoError(t, err)
+		require.NotNil(t, cfg.MessageTemplate)
+		assert.Equal(t, "{{.Next}}\n\nby {{.Author}}", *cfg.MessageTemplate)
+		require.NotNil(t, cfg.Prefix)
+		assert.Equal(t, "v", *cfg.Prefix)
+	})
+	t.Run("invalid file", func(t *testing.T) {
+		_, err := LoadConfig(tset(t, "prefix = ", ""))
+		assert.Error(t, err)
+	})
+	t.Run("invalid git config", func(t *testing.T) {
+		_, err := LoadConfig(tset(t, "", "vertag.fetch\nmaybe\x00"))
+		assert.Error(t, err)
+	})
+}
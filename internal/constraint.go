@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+var (
+	ErrUnsatisfied = errors.New("version does not satisfy the constraint")
+	// ErrNoVersionTag is returned for the current version when there is no version tag to check.
+	ErrNoVersionTag = errors.New("no version tag")
+)
+
+// Constraint is a range of versions like ">=1.2.0 <2 || >=3.1" (see semver.ParseRange).
+// Partial versions in comparisons are completed with zeros (e.g. ">=2" means ">=2.0.0"),
+// and a partial upper bound excludes its pre-releases (e.g. "<2" means "<2.0.0-0").
+type Constraint struct {
+	expr         string
+	alternatives [][]comparison
+}
+
+type comparison struct {
+	expr string
+	rng  semver.Range
+}
+
+var partialComparison = regexp.MustCompile(`^(!=|==|>=|<=|>|<|=)?(\d+)(\.\d+)?$`)
+
+func ParseConstraint(expr string) (*Constraint, error) {
+	c := &Constraint{expr: expr}
+	for _, alt := range strings.Split(expr, "||") {
+		var fields []string
+		for _, f := range strings.Fields(alt) {
+			if n := len(fields); n > 0 && strings.Trim(fields[n-1], "!=<>") == "" {
+				// an operator separated from the version (e.g. ">= 1.2.0")
+				fields[n-1] += f
+				continue
+			}
+			fields = append(fields, f)
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q: empty comparison", expr)
+		}
+		var comps []comparison
+		for _, f := range fields {
+			if m := partialComparison.FindStringSubmatch(f); m != nil {
+				f = m[1] + m[2] + m[3]
+				if m[3] == "" {
+					f += ".0"
+				}
+				f += ".0"
+				if m[1] == "<" {
+					f += "-0"
+				}
+			}
+			rng, err := semver.ParseRange(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %w", expr, err)
+			}
+			comps = append(comps, comparison{expr: f, rng: rng})
+		}
+		c.alternatives = append(c.alternatives, comps)
+	}
+	return c, nil
+}
+
+func (c *Constraint) String() string {
+	return c.expr
+}
+
+// Check tests whether the version satisfies the constraint.
+func (c *Constraint) Check(v semver.Version) bool {
+	for _, alt := range c.Explain(v) {
+		if alt.Satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+// Alternative is one of the alternatives ("||") in a constraint.
+type Alternative struct {
+	Satisfied   bool         `json:"satisfied"`
+	Comparisons []Comparison `json:"comparisons"`
+}
+
+// Comparison is a comparison of a version in a constraint (e.g. ">=1.2.0").
+type Comparison struct {
+	Comparison string `json:"comparison"`
+	Satisfied  bool   `json:"satisfied"`
+}
+
+// Explain checks the version with each comparison in the constraint.
+func (c *Constraint) Explain(v semver.Version) []Alternative {
+	alts := make([]Alternative, 0, len(c.alternatives))
+	for _, comps := range c.alternatives {
+		alt := Alternative{Satisfied: true}
+		for _, comp := range comps {
+			ok := comp.rng(v)
+			alt.Satisfied = alt.Satisfied && ok
+			alt.Comparisons = append(alt.Comparisons, Comparison{Comparison: comp.expr, Satisfied: ok})
+		}
+		alts = append(alts, alt)
+	}
+	return alts
+}
+
+// Satisfaction is the result of checking a version tag with a constraint.
+type Satisfaction struct {
+	Tag          string        `json:"tag"`
+	Version      *VersionInfo  `json:"version"`
+	Constraint   string        `json:"constraint"`
+	Satisfied    bool          `json:"satisfied"`
+	Alternatives []Alternative `json:"alternatives"`
+}
+
+// Satisfies checks the version tag with the constraint. If the tag is empty, the current version is checked.
+// It returns ErrUnsatisfied with the explanation if the version does not satisfy it.
+func (m *Manager) Satisfies(c *Constraint, tag string) (*Satisfaction, error) {
+	var v Ver
+	if tag == "" {
+		vers, err := m.getVers()
+		if err != nil {
+			return nil, err
+		}
+		if len(vers) == 0 {
+			return nil, fmt.Errorf("%w to check against %s", ErrNoVersionTag, c)
+		}
+		v = vers[len(vers)-1]
+		tag = m.tagName(v.String())
+	} else {
+		var ok bool
+		v, ok = m.parseTag(tag)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidVer, tag)
+		}
+	}
+	s := &Satisfaction{
+		Tag:          tag,
+		Version:      NewVersionInfo(v.Semver()),
+		Constraint:   c.String(),
+		Alternatives: c.Explain(v.Semver()),
+	}
+	for _, alt := range s.Alternatives {
+		s.Satisfied = s.Satisfied || alt.Satisfied
+	}
+	if !s.Satisfied {
+		return s, fmt.Errorf("%w: %s does not satisfy %s", ErrUnsatisfied, tag, c)
+	}
+	return s, nil
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraint(t *testing.T) {
+	for _, c := range []struct {
+		expr  string
+		ver   string
+		check bool
+	}{
+		{">=2.3.0 <3", "2.3.0", true},
+		{">=2.3.0 <3", "2.9.9", true},
+		{">=2.3.0 <3", "2.2.9", false},
+		{">=2.3.0 <3", "3.0.0", false},
+		{">=2.3.0 <3", "3.0.0-rc.1", false},
+		{">=2.3.0 <3.0.0", "3.0.0-rc.1", true},
+		{">= 2.3 < 3", "2.3.0", true},
+		{"<2.3", "2.2.5", true},
+		{"<1.0.0 || >=2", "1.5.0", false},
+		{"<1.0.0 || >=2", "2.0.0", true},
+		{"1.x", "1.4.0", true},
+		{"!=1.2.3", "1.2.3", false},
+	} {
+		constraint, err := ParseConstraint(c.expr)
+		require.NoError(t, err, c.expr)
+		assert.Equal(t, c.check, constraint.Check(semver.MustParse(c.ver)), "%s with %s", c.ver, c.expr)
+	}
+
+	for _, expr := range []string{"", ">=2.3.0 ||", "foo", ">=1.2.3.4"} {
+		_, err := ParseConstraint(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestConstraintExplain(t *testing.T) {
+	constraint, err := ParseConstraint(">=2.3.0 <3 || 4.x")
+	require.NoError(t, err)
+	assert.Equal(t, []Alternative{
+		{Satisfied: false, Comparisons: []Comparison{
+			{Comparison: ">=2.3.0", Satisfied: true},
+			{Comparison: "<3.0.0-0", Satisfied: false},
+		}},
+		{Satisfied: false, Comparisons: []Comparison{
+			{Comparison: "4.x", Satisfied: false},
+		}},
+	}, constraint.Explain(semver.MustParse("3.1.0")))
+}
+
+func TestSatisfies(t *testing.T) {
+	tset := func() (*bytes.Buffer, *MockRunner, *Manager) {
+		buffer := &bytes.Buffer{}
+		runner := &MockRunner{echo: buffer}
+		manager := &Manager{Prefix: "test", Tagger: Tagger{Runner: runner}}
+		return buffer, runner, manager
+	}
+	constraint, err := ParseConstraint(">=2.3.0 <3")
+	require.NoError(t, err)
+
+	t.Run("current version", func(t *testing.T) {
+		buf, run, man := tset()
+		run.output = strings.NewReader("test2.2.0\ntest2.4.1\n")
+		s, err := man.Satisfies(constraint, "")
+		require.NoError(t, err)
+		assert.Equal(t, "test2.4.1", s.Tag)
+		assert.Equal(t, &VersionInfo{Major: 2, Minor: 4, Patch: 1}, s.Version)
+		assert.Equal(t, ">=2.3.0 <3", s.Constraint)
+		assert.True(t, s.Satisfied)
+		assert.Equal(t, "git tag -l\n", buf.String())
+	})
+	t.Run("unsatisfied tag", func(t *testing.T) {
+		buf, _, man := tset()
+		s, err := man.Satisfies(constraint, "test3.0.0-rc.1")
+		assert.ErrorIs(t, err, ErrUnsatisfied)
+		require.NotNil(t, s)
+		assert.False(t, s.Satisfied)
+		assert.Empty(t, buf.String())
+	})
+	t.Run("without version tag", func(t *testing.T) {
+		_, run, man := tset()
+		run.output = strings.NewReader("foo\n")
+		s, err := man.Satisfies(constraint, "")
+		assert.ErrorIs(t, err, ErrNoVersionTag)
+		assert.Nil(t, s)
+	})
+	t.Run("invalid tag", func(t *testing.T) {
+		_, _, man := tset()
+		_, err := man.Satisfies(constraint, "v2.4.0")
+		assert.ErrorIs(t, err, ErrInvalidVer)
+	})
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Bump is a level of the version update.
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// ConventionalCommit is a commit message parsed with the Conventional Commits specification.
+// SPEC: https://www.conventionalcommits.org/en/v1.0.0/
+type ConventionalCommit struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+var (
+	conventionalHeader = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?: *(.*)$`)
+	breakingFooter     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+)
+
+func ParseConventionalCommit(message string) (ConventionalCommit, bool) {
+	lines := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+	match := conventionalHeader.FindStringSubmatch(lines[0])
+	if match == nil {
+		return ConventionalCommit{}, false
+	}
+	return ConventionalCommit{
+		Type:        strings.ToLower(match[1]),
+		Scope:       match[2],
+		Breaking:    match[3] != "" || (len(lines) > 1 && breakingFooter.MatchString(lines[1])),
+		Description: match[4],
+	}, true
+}
+
+func firstLine(message string) string {
+	return strings.SplitN(strings.TrimSpace(message), "\n", 2)[0]
+}
+
+// BumpRules maps commit types to the level of the version update.
+// Breaking changes always bump the major version.
+type BumpRules struct {
+	Major []string
+	Minor []string
+	Patch []string
+}
+
+func DefaultBumpRules() BumpRules {
+	return BumpRules{
+		Minor: []string{"feat"},
+		Patch: []string{"fix", "perf"},
+	}
+}
+
+func containsType(types []string, typ string) bool {
+	for _, t := range types {
+		if strings.EqualFold(t, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r BumpRules) Classify(message string) Bump {
+	c, ok := ParseConventionalCommit(message)
+	switch {
+	case !ok:
+		return BumpNone
+	case c.Breaking, containsType(r.Major, c.Type):
+		return BumpMajor
+	case containsType(r.Minor, c.Type):
+		return BumpMinor
+	case containsType(r.Patch, c.Type):
+		return BumpPatch
+	}
+	return BumpNone
+}
+
+func (r BumpRules) Decide(commits []Commit) Bump {
+	bump := BumpNone
+	for _, c := range commits {
+		if b := r.Classify(c.Message); b > bump {
+			bump = b
+		}
+	}
+	return bump
+}
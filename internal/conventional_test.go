@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	t.Run("type only", func(t *testing.T) {
+		c, ok := ParseConventionalCommit("feat: add a flag")
+		assert.True(t, ok)
+		assert.Equal(t, ConventionalCommit{Type: "feat", Description: "add a flag"}, c)
+	})
+	t.Run("with scope and bang", func(t *testing.T) {
+		c, ok := ParseConventionalCommit("fix(tagger)!: drop an option")
+		assert.True(t, ok)
+		assert.Equal(t, ConventionalCommit{Type: "fix", Scope: "tagger", Breaking: true, Description: "drop an option"}, c)
+	})
+	t.Run("breaking change footer", func(t *testing.T) {
+		c, ok := ParseConventionalCommit("refactor: rename\n\nBREAKING CHANGE: Foo is renamed to Bar")
+		assert.True(t, ok)
+		assert.True(t, c.Breaking)
+	})
+	t.Run("not conventional", func(t *testing.T) {
+		_, ok := ParseConventionalCommit("Merge branch 'main'")
+		assert.False(t, ok)
+	})
+}
+
+func TestBumpRules(t *testing.T) {
+	rules := DefaultBumpRules()
+	t.Run("classify", func(t *testing.T) {
+		assert.Equal(t, BumpMinor, rules.Classify("feat: add"))
+		assert.Equal(t, BumpPatch, rules.Classify("fix: bug"))
+		assert.Equal(t, BumpMajor, rules.Classify("fix!: bug"))
+		assert.Equal(t, BumpMajor, rules.Classify("docs: foo\n\nBREAKING-CHANGE: bar"))
+		assert.Equal(t, BumpNone, rules.Classify("docs: readme"))
+		assert.Equal(t, BumpNone, rules.Classify("update readme"))
+	})
+	t.Run("custom rules", func(t *testing.T) {
+		rules := BumpRules{Major: []string{"epic"}, Patch: []string{"docs"}}
+		assert.Equal(t, BumpMajor, rules.Classify("epic: rewrite"))
+		assert.Equal(t, BumpPatch, rules.Classify("Docs: readme"))
+		assert.Equal(t, BumpNone, rules.Classify("feat: add"))
+	})
+	t.Run("decide", func(t *testing.T) {
+		assert.Equal(t, BumpNone, rules.Decide(nil))
+		assert.Equal(t, BumpMinor, rules.Decide([]Commit{
+			{Message: "fix: bug"},
+			{Message: "feat: add"},
+			{Message: "chore: deps"},
+		}))
+	})
+}
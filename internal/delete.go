@@ -0,0 +1,256 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/blang/semver/v4"
+)
+
+var ErrNoVersionToDelete = errors.New("no version tag to delete")
+
+// DeleteOptions selects version tags to delete. If nothing is given, the current version tag is selected.
+type DeleteOptions struct {
+	Tags []string
+	// Range selects versions in the range (e.g. ">=1.3.0-0 <1.3.0" for pre-releases of v1.3.0).
+	Range semver.Range
+}
+
+// DeleteTarget is a tag to be deleted or moved.
+type DeleteTarget struct {
+	Tag string `json:"tag"`
+	// To is the version tag which the ancestor is moved to. Empty means the tag is deleted.
+	To string `json:"to,omitempty"`
+	// Remote is true if the tag exists in the remote to push to.
+	Remote bool `json:"remote,omitempty"`
+}
+
+// DeletePlan is what Delete changes: version tags to delete, and ancestors to move back.
+type DeletePlan struct {
+	Previous  Ver
+	Next      Ver
+	Tags      []DeleteTarget
+	Ancestors []DeleteTarget
+}
+
+// WriteSummary writes refs which will be deleted or moved in the local and the remote.
+func (p *DeletePlan) WriteSummary(w io.Writer, remote string) error {
+	where := func(target DeleteTarget) string {
+		if target.Remote {
+			return "in the local and " + remote
+		}
+		return "in the local"
+	}
+	for _, target := range append(append([]DeleteTarget{}, p.Tags...), p.Ancestors...) {
+		var err error
+		if target.To == "" {
+			_, err = fmt.Fprintf(w, "delete refs/tags/%s %s\n", target.Tag, where(target))
+		} else {
+			_, err = fmt.Fprintf(w, "move refs/tags/%s to %s %s\n", target.Tag, target.To, where(target))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlanDelete selects version tags to delete, and ancestors to move back to the highest remaining version in their line.
+func (m *Manager) PlanDelete(opts DeleteOptions) (*DeletePlan, error) {
+	tags, err := m.Tagger.GetTags(m.Fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current ver: %w", err)
+	}
+	vers := m.parseVers(tags)
+	if len(vers) == 0 {
+		return nil, ErrNoVersionToDelete
+	}
+	selected := map[string]bool{}
+	switch {
+	case len(opts.Tags) > 0 || opts.Range != nil:
+		for _, tag := range opts.Tags {
+			v, ok := m.parseTag(tag)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidVer, tag)
+			}
+			if !containsVer(vers, v) {
+				return nil, fmt.Errorf("%w: %s not found", ErrNoVersionToDelete, tag)
+			}
+			selected[v.String()] = true
+		}
+		if opts.Range != nil {
+			for _, v := range vers {
+				if opts.Range(v.Semver()) {
+					selected[v.String()] = true
+				}
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("%w in the range", ErrNoVersionToDelete)
+		}
+	default:
+		selected[vers[len(vers)-1].String()] = true
+	}
+
+	var remaining Vers
+	plan := &DeletePlan{Previous: vers[len(vers)-1], Next: m.zeroVer()}
+	for _, v := range vers {
+		if selected[v.String()] {
+			plan.Tags = append(plan.Tags, DeleteTarget{Tag: m.tagName(v.String())})
+		} else {
+			remaining = append(remaining, v)
+		}
+	}
+	if len(remaining) > 0 {
+		plan.Next = remaining[len(remaining)-1]
+	}
+
+	if m.Ancestors {
+		exists := map[string]bool{}
+		for _, tag := range tags {
+			exists[tag] = true
+		}
+		seen := map[string]bool{}
+		for _, target := range plan.Tags {
+			v, _ := m.parseTag(target.Tag)
+			for _, anc := range m.ancestors(v) {
+				if seen[anc.tag] || !exists[anc.tag] {
+					continue
+				}
+				seen[anc.tag] = true
+				before, after := highestInLine(vers, v, anc.level), highestInLine(remaining, v, anc.level)
+				if before != nil && after != nil && before.Compare(after) == 0 {
+					// the ancestor keeps pointing at the remaining version
+					continue
+				}
+				move := DeleteTarget{Tag: anc.tag}
+				if after != nil {
+					move.To = m.tagName(after.String())
+				}
+				plan.Ancestors = append(plan.Ancestors, move)
+			}
+		}
+	}
+
+	if m.Tagger.PushTo != "" {
+		remotes, err := m.Tagger.GetRemoteTags(m.Tagger.PushTo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tags in %s: %w", m.Tagger.PushTo, err)
+		}
+		inRemote := map[string]bool{}
+		for _, r := range remotes {
+			inRemote[r.Name] = true
+		}
+		for i := range plan.Tags {
+			plan.Tags[i].Remote = inRemote[plan.Tags[i].Tag]
+		}
+		for i := range plan.Ancestors {
+			plan.Ancestors[i].Remote = inRemote[plan.Ancestors[i].Tag]
+		}
+	}
+	return plan, nil
+}
+
+func containsVer(vers Vers, v Ver) bool {
+	for _, u := range vers {
+		// build metadata is compared too, to select the exact tag
+		if u.String() == v.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// highestInLine finds the highest version in the same line of the level ("major" or "minor") as v.
+func highestInLine(vers Vers, v Ver, level string) Ver {
+	for i := len(vers) - 1; i >= 0; i-- {
+		if sameLine(vers[i], v, level) {
+			return vers[i]
+		}
+	}
+	return nil
+}
+
+// Delete deletes the version tags and moves the ancestors in the plan, and pushes them at once.
+// The ancestors are moved with the messages like on updates, so that they keep the type of the tags.
+// If it fails, the tags deleted or moved in the local are rolled back.
+func (m *Manager) Delete(plan *DeletePlan, msg []string, file string) (*Result, error) {
+	if err := m.runHook(HookPreDelete, "delete", plan.Previous, plan.Next); err != nil {
+		return nil, err
+	}
+	res := &Result{
+		Previous: m.tagName(plan.Previous.String()),
+		Next:     m.tagName(plan.Next.String()),
+		Version:  NewVersionInfo(plan.Next.Semver()),
+		Remote:   m.Tagger.PushTo,
+	}
+	var updates []*TagUpdate
+	for _, target := range plan.Tags {
+		v, _ := m.parseTag(target.Tag)
+		update, err := m.deleteVer(v)
+		if err != nil {
+			return nil, m.rollback(fmt.Errorf("failed to delete %s: %w", target.Tag, err), false, updates)
+		}
+		updates = append(updates, update)
+		res.Deleted = append(res.Deleted, target.Tag)
+	}
+	for _, target := range plan.Ancestors {
+		var update *TagUpdate
+		var err error
+		if target.To == "" {
+			update, err = m.Tagger.RemoveTag(target.Tag)
+		} else {
+			var ancMsg []string
+			ancMsg, err = m.ancestorMessages(plan, target, msg, file)
+			if err != nil {
+				return nil, m.rollback(err, false, updates)
+			}
+			update, err = m.Tagger.MoveTagTo(target.Tag, target.To, ancMsg, file)
+		}
+		if err != nil {
+			return nil, m.rollback(fmt.Errorf("failed to update %s: %w", target.Tag, err), false, updates)
+		}
+		updates = append(updates, update)
+		if target.To == "" {
+			res.Deleted = append(res.Deleted, target.Tag)
+		} else {
+			res.Moved = append(res.Moved, target.Tag)
+		}
+	}
+	if err := m.Tagger.PushTags(false, updates...); err != nil {
+		return nil, m.rollback(fmt.Errorf("failed to push tags: %w", err), false, updates)
+	}
+	if err := m.runHook(HookPostDelete, "delete", plan.Previous, plan.Next); err != nil {
+		// the tags are already deleted
+		res.Warnings = append(res.Warnings, err.Error())
+	}
+	return res, nil
+}
+
+// ancestorMessages builds the messages for the ancestor moved back, with the MessageTemplate as on updates.
+func (m *Manager) ancestorMessages(plan *DeletePlan, target DeleteTarget, msg []string, file string) ([]string, error) {
+	if m.MessageTemplate == "" {
+		return m.messages(nil, msg, file)
+	}
+	author, err := m.Tagger.GetIdent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the tagger: %w", err)
+	}
+	data := &MessageData{
+		Previous: m.tagName(plan.Previous.String()),
+		Next:     target.To,
+		Kind:     "delete",
+		Author:   author,
+		Date:     now(),
+		Tag:      target.Tag,
+	}
+	if to, ok := m.parseTag(target.To); ok {
+		for _, anc := range m.ancestors(to) {
+			if anc.tag == target.Tag {
+				data.Ancestor = anc.level
+			}
+		}
+	}
+	return m.messages(data, msg, file)
+}
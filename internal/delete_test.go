@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelete(t *testing.T) {
+	const tags = "test1\ntest1.2\ntest1.3\ntest1.2.0\ntest1.2.1\ntest1.3.0-rc.1\ntest1.3.0-rc.2\n"
+	tset := func() (*bytes.Buffer, *MockRunner, *Manager) {
+		buf := &bytes.Buffer{}
+		run := &MockRunner{echo: buf, outputs: map[string]io.Reader{
+			"tag -l":                  strings.NewReader(tags),
+			"ls-remote --tags origin": strings.NewReader("aaa\trefs/tags/test1\nbbb\trefs/tags/test1.3.0-rc.1\n"),
+		}}
+		return buf, run, &Manager{Prefix: "test", Tagger: Tagger{Runner: run}}
+	}
+
+	t.Run("plan current", func(t *testing.T) {
+		_, _, man := tset()
+		plan, err := man.PlanDelete(DeleteOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, &DeletePlan{
+			Previous: SemVer(semver.MustParse("1.3.0-rc.2")),
+			Next:     SemVer(semver.MustParse("1.3.0-rc.1")),
+			Tags:     []DeleteTarget{{Tag: "test1.3.0-rc.2"}},
+		}, plan)
+	})
+	t.Run("plan tags and range with ancestors", func(t *testing.T) {
+		_, _, man := tset()
+		man.Ancestors = true
+		man.Tagger.PushTo = "origin"
+		r, err := ParseConstraint(">=1.3.0-0 <1.3.0")
+		require.NoError(t, err)
+		plan, err := man.PlanDelete(DeleteOptions{Tags: []string{"test1.2.0"}, Range: r.Check})
+		require.NoError(t, err)
+		assert.Equal(t, &DeletePlan{
+			Previous: SemVer(semver.MustParse("1.3.0-rc.2")),
+			Next:     SemVer(semver.MustParse("1.2.1")),
+			Tags:     []DeleteTarget{{Tag: "test1.2.0"}, {Tag: "test1.3.0-rc.1", Remote: true}, {Tag: "test1.3.0-rc.2"}},
+			Ancestors: []DeleteTarget{
+				{Tag: "test1", To: "test1.2.1", Remote: true},
+				{Tag: "test1.3"},
+			},
+		}, plan, "test1.2 keeps pointing at test1.2.1")
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, plan.WriteSummary(buf, "origin"))
+		assert.Equal(t, "delete refs/tags/test1.2.0 in the local\n"+
+			"delete refs/tags/test1.3.0-rc.1 in the local and origin\n"+
+			"delete refs/tags/test1.3.0-rc.2 in the local\n"+
+			"move refs/tags/test1 to test1.2.1 in the local and origin\n"+
+			"delete refs/tags/test1.3 in the local\n", buf.String())
+	})
+	t.Run("plan unknown tag", func(t *testing.T) {
+		_, _, man := tset()
+		_, err := man.PlanDelete(DeleteOptions{Tags: []string{"test1.9.0"}})
+		assert.ErrorIs(t, err, ErrNoVersionToDelete)
+		_, _, man = tset()
+		_, err = man.PlanDelete(DeleteOptions{Tags: []string{"foo"}})
+		assert.ErrorIs(t, err, ErrInvalidVer)
+	})
+
+	plan := &DeletePlan{
+		Previous:  SemVer(semver.MustParse("1.3.0-rc.1")),
+		Next:      SemVer(semver.MustParse("1.2.1")),
+		Tags:      []DeleteTarget{{Tag: "test1.3.0-rc.1", Remote: true}},
+		Ancestors: []DeleteTarget{{Tag: "test1", To: "test1.2.1", Remote: true}, {Tag: "test1.3"}},
+	}
+	outputs := func() map[string]io.Reader {
+		return map[string]io.Reader{
+			"rev-parse --verify --quiet refs/tags/test1.3.0-rc.1":                          strings.NewReader("obj\n"),
+			"rev-parse --verify --quiet refs/tags/test1":                                   strings.NewReader("anc\n"),
+			"rev-parse --verify --quiet refs/tags/test1.3":                                 strings.NewReader("minor\n"),
+			"rev-parse --verify --quiet test1.2.1^{commit}":                                strings.NewReader("prev\n"),
+			"ls-remote --tags origin refs/tags/test1.3.0-rc.1 refs/tags/test1.3.0-rc.1^{}": strings.NewReader("obj\trefs/tags/test1.3.0-rc.1\n"),
+			"ls-remote --tags origin refs/tags/test1 refs/tags/test1^{}":                   strings.NewReader("anc\trefs/tags/test1\n"),
+			"rev-parse --verify --quiet refs/tags/test1^{commit}":                          strings.NewReader("cur\n"),
+			"ls-remote --tags origin refs/tags/test1.3 refs/tags/test1.3^{}":               strings.NewReader(""),
+			"rev-parse --verify --quiet refs/tags/test1.3^{commit}":                        strings.NewReader("cur\n"),
+		}
+	}
+	t.Run("delete and move back", func(t *testing.T) {
+		buf, run, man := tset()
+		run.outputs = outputs()
+		man.Tagger.PushTo = "origin"
+		res, err := man.Delete(plan, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test1.3.0-rc.1", "test1.3"}, res.Deleted)
+		assert.Equal(t, []string{"test1"}, res.Moved)
+		assert.Equal(t, "test1.2.1", res.Next)
+		assert.Contains(t, buf.String(), "git tag -d test1.3.0-rc.1\n")
+		assert.Contains(t, buf.String(), "git tag --force test1 prev\n")
+		assert.Contains(t, buf.String(), "git tag -d test1.3\n")
+		assert.True(t, strings.HasSuffix(buf.String(),
+			"git push --atomic --force-with-lease=refs/tags/test1.3.0-rc.1:obj --force-with-lease=refs/tags/test1:anc origin :refs/tags/test1.3.0-rc.1 refs/tags/test1\n"), buf.String())
+	})
+	t.Run("move back with messages", func(t *testing.T) {
+		buf, run, man := tset()
+		run.outputs = outputs()
+		man.Tagger.PushTo = "origin"
+		_, err := man.Delete(plan, []string{"test-msg"}, "")
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "git tag --force --message test-msg test1 prev\n")
+
+		buf, run, man = tset()
+		run.outputs = outputs()
+		run.outputs["var GIT_COMMITTER_IDENT"] = strings.NewReader("T <t@e> 0 +0000\n")
+		man.Tagger.PushTo = "origin"
+		man.Ancestors = true
+		man.MessageTemplate = "{{.Tag}} ({{.Ancestor}}) back to {{.Next}} from {{.Previous}}"
+		_, err = man.Delete(plan, nil, "")
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "git tag --force --message \"test1 (major) back to test1.2.1 from test1.3.0-rc.1\" test1 prev\n")
+	})
+	t.Run("roll back on failure to push", func(t *testing.T) {
+		buf, run, man := tset()
+		run.outputs = outputs()
+		run.errs = map[string]error{
+			"push --atomic --force-with-lease=refs/tags/test1.3.0-rc.1:obj --force-with-lease=refs/tags/test1:anc origin :refs/tags/test1.3.0-rc.1 refs/tags/test1": errors.New("stale info"),
+		}
+		man.Tagger.PushTo = "origin"
+		_, err := man.Delete(plan, nil, "")
+		assert.ErrorContains(t, err, "rolled back")
+		assert.True(t, strings.HasSuffix(buf.String(),
+			"git update-ref refs/tags/test1.3 minor\ngit update-ref refs/tags/test1 anc\ngit update-ref refs/tags/test1.3.0-rc.1 obj\n"), buf.String())
+	})
+}
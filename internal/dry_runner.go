@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"encoding/csv"
 	"io"
 	"os"
@@ -11,11 +12,12 @@ type DryRunner struct {
 	echo *csv.Writer
 }
 
-func NewDryRunner() Runner {
+// NewDryRunner builds a Runner which only shows commands with side effects, and runs others with the runner.
+func NewDryRunner(runner Runner) Runner {
 	w := csv.NewWriter(os.Stdout)
 	w.Comma = ' '
 	return &DryRunner{
-		Runner: NewGitRunner(),
+		Runner: runner,
 		echo:   w,
 	}
 }
@@ -31,4 +33,11 @@ func (c *DryRunner) Run(sideEffects bool, stdout io.Writer, args ...string) erro
 	return nil
 }
 
-var _ Runner = (*DryRunner)(nil)
+func (c *DryRunner) RunContext(ctx context.Context, sideEffects bool, stdout io.Writer, args ...string) error {
+	if runner, ok := c.Runner.(ContextRunner); ok && !sideEffects {
+		return runner.RunContext(ctx, sideEffects, stdout, args...)
+	}
+	return c.Run(sideEffects, stdout, args...)
+}
+
+var _ ContextRunner = (*DryRunner)(nil)
@@ -93,3 +93,31 @@ func (f BuildFlag) String() string {
 func (f BuildFlag) List() []string {
 	return []string(f)
 }
+
+type RangeFlag struct {
+	*Constraint
+}
+
+func (f *RangeFlag) Set(s string) error {
+	c, err := ParseConstraint(s)
+	if err != nil {
+		return err
+	}
+	f.Constraint = c
+	return nil
+}
+
+func (f *RangeFlag) String() string {
+	if f.Constraint == nil {
+		return ""
+	}
+	return f.Constraint.String()
+}
+
+// Range gets the function to check versions: nil if it is not set.
+func (f *RangeFlag) Range() semver.Range {
+	if f.Constraint == nil {
+		return nil
+	}
+	return f.Check
+}
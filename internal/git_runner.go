@@ -1,8 +1,11 @@
 package internal
 
 import (
+	"context"
 	"io"
+	"os"
 	"os/exec"
+	"time"
 )
 
 type GitRunner struct{}
@@ -12,9 +15,16 @@ func NewGitRunner() Runner {
 }
 
 func (c *GitRunner) Run(sideEffects bool, stdout io.Writer, args ...string) error {
-	cmd := exec.Command("git", args...)
+	return c.RunContext(context.Background(), sideEffects, stdout, args...)
+}
+
+func (c *GitRunner) RunContext(ctx context.Context, sideEffects bool, stdout io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Stdout = stdout
+	// let git clean up (e.g. lock files) before it is killed
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 5 * time.Second
 	return cmd.Run()
 }
 
-var _ Runner = (*GitRunner)(nil)
+var _ ContextRunner = (*GitRunner)(nil)
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitRunner(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// isolate from the user's config (e.g. tag.gpgSign)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	run := func(t *testing.T, dir string, args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	// tset makes a bare remote and returns a function to clone it with a new commit.
+	tset := func(t *testing.T) func(t *testing.T) *Manager {
+		remote := t.TempDir()
+		run(t, remote, "init", "--quiet", "--bare")
+		return func(t *testing.T) *Manager {
+			dir := t.TempDir()
+			run(t, dir, "clone", "--quiet", remote, ".")
+			run(t, dir, "commit", "--quiet", "--allow-empty", "--message", "work")
+			return &Manager{
+				Prefix:    "v",
+				Ancestors: true,
+				Tagger:    Tagger{Runner: NewGitRunner(), Workdir: dir, PushTo: "origin"},
+			}
+		}
+	}
+
+	t.Run("fetch ancestors moved by another clone", func(t *testing.T) {
+		clone := tset(t)
+		first := clone(t)
+		_, err := first.UpdatePatch(nil, nil, nil, "")
+		require.NoError(t, err)
+
+		second := clone(t)
+		second.Fetch = true
+
+		run(t, first.Tagger.Workdir, "commit", "--quiet", "--allow-empty", "--message", "next")
+		res, err := first.UpdatePatch(nil, nil, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v0", "v0.0"}, res.Moved)
+
+		ver, err := second.GetVer()
+		require.NoError(t, err)
+		assert.Equal(t, "v0.0.2", ver)
+		assert.Equal(t,
+			run(t, first.Tagger.Workdir, "rev-parse", "v0^{commit}"),
+			run(t, second.Tagger.Workdir, "rev-parse", "v0^{commit}"),
+			"the moved ancestor is fetched")
+		res, err = second.UpdatePatch(nil, nil, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, "v0.0.3", res.Next)
+	})
+
+	t.Run("retry with ancestors", func(t *testing.T) {
+		clone := tset(t)
+		first := clone(t)
+		_, err := first.UpdatePatch(nil, nil, nil, "")
+		require.NoError(t, err)
+
+		second := clone(t)
+		second.Retry = 2
+
+		run(t, first.Tagger.Workdir, "commit", "--quiet", "--allow-empty", "--message", "next")
+		_, err = first.UpdatePatch(nil, nil, nil, "")
+		require.NoError(t, err)
+
+		// the second has the ancestors which the first moved
+		res, err := second.UpdatePatch(nil, nil, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, "v0.0.2", res.Previous)
+		assert.Equal(t, "v0.0.3", res.Next)
+		assert.Equal(t, 1, res.Retries)
+		assert.Equal(t, []string{"v0", "v0.0"}, res.Moved)
+	})
+}
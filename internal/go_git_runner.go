@@ -0,0 +1,646 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+var ErrUnsupportedCommand = errors.New("unsupported git command")
+
+// GoGitRunner runs the git commands which the Tagger calls in-process, without the git binary.
+type GoGitRunner struct {
+	// Repository is used instead of opening the repository in the working directory (or the "-C <path>").
+	Repository *git.Repository
+}
+
+func NewGoGitRunner() Runner {
+	return &GoGitRunner{}
+}
+
+func (c *GoGitRunner) open(dir string) (*git.Repository, error) {
+	if c.Repository != nil {
+		return c.Repository, nil
+	}
+	if dir == "" {
+		dir = "."
+	}
+	return git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+}
+
+func (c *GoGitRunner) Run(sideEffects bool, stdout io.Writer, args ...string) error {
+	return c.RunContext(context.Background(), sideEffects, stdout, args...)
+}
+
+func (c *GoGitRunner) RunContext(ctx context.Context, sideEffects bool, stdout io.Writer, args ...string) error {
+	var dir string
+	if len(args) >= 2 && args[0] == "-C" {
+		dir, args = args[1], args[2:]
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("%w: no command", ErrUnsupportedCommand)
+	}
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	repo, err := c.open(dir)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "tag":
+		return c.tag(repo, stdout, args[1:])
+	case "add":
+		return c.add(repo, args[1:])
+	case "commit":
+		return c.commit(repo, args[1:])
+	case "reset":
+		return c.reset(repo, args[1:])
+	case "fetch":
+		return c.fetch(ctx, repo, args[1:])
+	case "push":
+		return c.push(repo, args[1:])
+	case "ls-remote":
+		return c.lsRemote(ctx, repo, stdout, args[1:])
+	case "update-ref":
+		return c.updateRef(repo, args[1:])
+	case "log":
+		return c.log(repo, stdout, args[1:])
+	case "rev-parse":
+		return c.revParse(repo, stdout, args[1:])
+	case "config":
+		return c.config(repo, stdout, args[1:])
+	case "var":
+		return c.ident(repo, stdout, args[1:])
+	}
+	return unsupported(args)
+}
+
+func unsupported(args []string) error {
+	return fmt.Errorf("%w: git %s", ErrUnsupportedCommand, strings.Join(args, " "))
+}
+
+func (c *GoGitRunner) tag(repo *git.Repository, stdout io.Writer, args []string) error {
+	switch {
+	case len(args) >= 1 && args[0] == "-l":
+		var merged string
+		switch {
+		case len(args) == 1:
+		case len(args) == 3 && args[1] == "--merged":
+			merged = args[2]
+		default:
+			return unsupported(append([]string{"tag"}, args...))
+		}
+		return c.listTags(repo, stdout, merged)
+	case len(args) == 2 && args[0] == "--points-at":
+		return c.pointsAt(repo, stdout, args[1])
+	case len(args) >= 2 && args[0] == "-d":
+		for _, name := range args[1:] {
+			if err := repo.DeleteTag(name); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return nil
+	}
+	return c.createTag(repo, args)
+}
+
+// peel resolves the commit which the tag points at.
+func peel(repo *git.Repository, hash plumbing.Hash) (plumbing.Hash, error) {
+	for {
+		tag, err := repo.TagObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return hash, nil
+		}
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if tag.TargetType != plumbing.TagObject {
+			return tag.Target, nil
+		}
+		hash = tag.Target
+	}
+}
+
+func resolveCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, err
+	}
+	peeled, err := peel(repo, *hash)
+	if err != nil {
+		return nil, err
+	}
+	return repo.CommitObject(peeled)
+}
+
+// eachTag calls the fn with the name and the commit of each tag in order of the name.
+func eachTag(repo *git.Repository, fn func(name string, commit plumbing.Hash) error) error {
+	iter, err := repo.Tags()
+	if err != nil {
+		return err
+	}
+	type tag struct {
+		name string
+		hash plumbing.Hash
+	}
+	var tags []tag
+	if err := iter.ForEach(func(ref *plumbing.Reference) error {
+		tags = append(tags, tag{name: ref.Name().Short(), hash: ref.Hash()})
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].name < tags[j].name })
+	for _, t := range tags {
+		commit, err := peel(repo, t.hash)
+		if err != nil {
+			return err
+		}
+		if err := fn(t.name, commit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *GoGitRunner) listTags(repo *git.Repository, stdout io.Writer, merged string) error {
+	var head *object.Commit
+	if merged != "" {
+		var err error
+		head, err = resolveCommit(repo, merged)
+		if err != nil {
+			return err
+		}
+	}
+	return eachTag(repo, func(name string, hash plumbing.Hash) error {
+		if head != nil {
+			commit, err := repo.CommitObject(hash)
+			if err != nil {
+				// a tag for a non-commit object is never merged
+				return nil
+			}
+			if ok, err := commit.IsAncestor(head); err != nil || !ok {
+				return err
+			}
+		}
+		_, err := fmt.Fprintln(stdout, name)
+		return err
+	})
+}
+
+func (c *GoGitRunner) pointsAt(repo *git.Repository, stdout io.Writer, rev string) error {
+	target, err := resolveCommit(repo, rev)
+	if err != nil {
+		return err
+	}
+	return eachTag(repo, func(name string, hash plumbing.Hash) error {
+		if hash != target.Hash {
+			return nil
+		}
+		_, err := fmt.Fprintln(stdout, name)
+		return err
+	})
+}
+
+func (c *GoGitRunner) createTag(repo *git.Repository, args []string) error {
+	var name, rev string
+	var message []string
+	var force bool
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--force":
+			force = true
+		case args[i] == "--message" && i+1 < len(args):
+			i++
+			message = append(message, args[i])
+		case args[i] == "--file" && i+1 < len(args):
+			i++
+			var data []byte
+			var err error
+			if args[i] == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(args[i])
+			}
+			if err != nil {
+				return err
+			}
+			message = append(message, string(data))
+		case !strings.HasPrefix(args[i], "-") && name == "":
+			name = args[i]
+		case !strings.HasPrefix(args[i], "-") && rev == "":
+			rev = args[i]
+		default:
+			return unsupported(append([]string{"tag"}, args...))
+		}
+	}
+	if name == "" {
+		return unsupported(append([]string{"tag"}, args...))
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+	target, err := resolveCommit(repo, rev)
+	if err != nil {
+		return err
+	}
+	var opts *git.CreateTagOptions
+	if len(message) > 0 {
+		opts = &git.CreateTagOptions{Message: strings.Join(message, "\n\n")}
+		if name, email := os.Getenv("GIT_COMMITTER_NAME"), os.Getenv("GIT_COMMITTER_EMAIL"); name != "" && email != "" {
+			opts.Tagger = &object.Signature{Name: name, Email: email, When: time.Now()}
+		}
+	}
+	if force {
+		if err := repo.DeleteTag(name); err != nil && !errors.Is(err, git.ErrTagNotFound) {
+			return err
+		}
+	}
+	_, err = repo.CreateTag(name, target.Hash, opts)
+	return err
+}
+
+func remoteFor(repo *git.Repository, name string) (*git.Remote, error) {
+	remote, err := repo.Remote(name)
+	if err == nil {
+		return remote, nil
+	}
+	if !errors.Is(err, git.ErrRemoteNotFound) {
+		return nil, err
+	}
+	// a URL or a path
+	return git.NewRemote(repo.Storer, &config.RemoteConfig{Name: "anonymous", URLs: []string{name}}), nil
+}
+
+func (c *GoGitRunner) fetch(ctx context.Context, repo *git.Repository, args []string) error {
+	remoteName := git.DefaultRemoteName
+	if len(args) < 2 || args[0] != "--tags" || args[1] != "--force" {
+		return unsupported(append([]string{"fetch"}, args...))
+	}
+	switch len(args) {
+	case 2:
+	case 3:
+		remoteName = args[2]
+	default:
+		return unsupported(append([]string{"fetch"}, args...))
+	}
+	remote, err := remoteFor(repo, remoteName)
+	if err != nil {
+		return err
+	}
+	err = remote.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: remote.Config().Name,
+		RefSpecs:   []config.RefSpec{"+refs/tags/*:refs/tags/*"},
+		Tags:       git.AllTags,
+		Force:      true,
+	})
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return err
+}
+
+func tagRefSpec(spec string) config.RefSpec {
+	if strings.HasPrefix(spec, ":") {
+		return config.RefSpec(":" + plumbing.NewTagReferenceName(spec[1:]).String())
+	}
+	ref := plumbing.NewTagReferenceName(spec).String()
+	return config.RefSpec(ref + ":" + ref)
+}
+
+func (c *GoGitRunner) push(repo *git.Repository, args []string) error {
+	// leases are checked by listing the remote, since go-git checks them only for branches
+	leases := map[plumbing.ReferenceName]string{}
+	var atomic bool
+	for ; len(args) > 0 && strings.HasPrefix(args[0], "--"); args = args[1:] {
+		switch {
+		case args[0] == "--atomic":
+			atomic = true
+		case strings.HasPrefix(args[0], "--force-with-lease="):
+			lease := strings.SplitN(strings.TrimPrefix(args[0], "--force-with-lease="), ":", 2)
+			if len(lease) != 2 {
+				return unsupported(append([]string{"push"}, args...))
+			}
+			leases[plumbing.ReferenceName(lease[0])] = lease[1]
+		default:
+			return unsupported(append([]string{"push"}, args...))
+		}
+	}
+	if len(args) < 2 || strings.HasPrefix(args[0], "-") {
+		return unsupported(append([]string{"push"}, args...))
+	}
+	remote, err := remoteFor(repo, args[0])
+	if err != nil {
+		return err
+	}
+	var specs []config.RefSpec
+	// existing tags are rejected like git, while go-git updates them if it is fast-forward
+	var tags []plumbing.ReferenceName
+	for _, spec := range args[1:] {
+		switch {
+		case strings.HasPrefix(spec, "-"):
+			return unsupported(append([]string{"push"}, args...))
+		case spec == "HEAD":
+			head, err := repo.Head()
+			if err != nil {
+				return err
+			}
+			if !head.Name().IsBranch() {
+				return errors.New("HEAD is not a branch")
+			}
+			specs = append(specs, config.RefSpec(head.Name()+":"+head.Name()))
+		case strings.HasPrefix(spec, ":refs/"):
+			specs = append(specs, config.RefSpec(spec))
+		case strings.HasPrefix(spec, "refs/"):
+			ref := plumbing.ReferenceName(spec)
+			if _, ok := leases[ref]; ok {
+				specs = append(specs, config.RefSpec("+"+spec+":"+spec))
+			} else {
+				specs = append(specs, config.RefSpec(spec+":"+spec))
+				if ref.IsTag() {
+					tags = append(tags, ref)
+				}
+			}
+		default:
+			specs = append(specs, tagRefSpec(spec))
+			if !strings.HasPrefix(spec, ":") {
+				tags = append(tags, plumbing.NewTagReferenceName(spec))
+			}
+		}
+	}
+	if len(leases) > 0 || len(tags) > 0 {
+		refs, err := remote.List(&git.ListOptions{})
+		if err != nil && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			return err
+		}
+		current := map[plumbing.ReferenceName]string{}
+		for _, ref := range refs {
+			current[ref.Name()] = ref.Hash().String()
+		}
+		for name, expect := range leases {
+			if current[name] != expect {
+				return fmt.Errorf("stale info: %s", name)
+			}
+		}
+		for _, name := range tags {
+			if hash, ok := current[name]; ok {
+				local, err := repo.Reference(name, false)
+				if err != nil || local.Hash().String() != hash {
+					return fmt.Errorf("rejected: %s already exists", name)
+				}
+			}
+		}
+	}
+	err = remote.Push(&git.PushOptions{RemoteName: remote.Config().Name, RefSpecs: specs, Atomic: atomic})
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return err
+}
+
+func (c *GoGitRunner) lsRemote(ctx context.Context, repo *git.Repository, stdout io.Writer, args []string) error {
+	if len(args) < 1 || args[0] != "--tags" {
+		return unsupported(append([]string{"ls-remote"}, args...))
+	}
+	remoteName, patterns := git.DefaultRemoteName, []string{}
+	if len(args) >= 2 {
+		remoteName, patterns = args[1], args[2:]
+	}
+	remote, err := remoteFor(repo, remoteName)
+	if err != nil {
+		return err
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{PeelingOption: git.AppendPeeled})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Name() < refs[j].Name() })
+	for _, ref := range refs {
+		name := ref.Name().String()
+		if !strings.HasPrefix(name, "refs/tags/") || !matchRefPatterns(name, patterns) {
+			continue
+		}
+		if _, err := fmt.Fprintf(stdout, "%s\t%s\n", ref.Hash(), name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchRefPatterns matches the ref name with the tails of the patterns like "git ls-remote".
+func matchRefPatterns(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if name == p || strings.HasSuffix(name, "/"+p) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathspecs gets paths after "--" relative to the root of the worktree.
+func pathspecs(tree *git.Worktree, args []string) ([]string, error) {
+	if len(args) < 2 || args[0] != "--" {
+		return nil, errors.New("no pathspec")
+	}
+	root := tree.Filesystem.Root()
+	var paths []string
+	for _, arg := range args[1:] {
+		if filepath.IsAbs(arg) {
+			rel, err := filepath.Rel(root, arg)
+			if err != nil {
+				return nil, err
+			}
+			arg = rel
+		}
+		paths = append(paths, filepath.ToSlash(arg))
+	}
+	return paths, nil
+}
+
+func (c *GoGitRunner) add(repo *git.Repository, args []string) error {
+	tree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	paths, err := pathspecs(tree, args)
+	if err != nil {
+		return unsupported(append([]string{"add"}, args...))
+	}
+	for _, path := range paths {
+		if _, err := tree.Add(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *GoGitRunner) commit(repo *git.Repository, args []string) error {
+	if len(args) < 3 || args[0] != "--message" {
+		return unsupported(append([]string{"commit"}, args...))
+	}
+	tree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	// the paths should be added already: unlike git, other staged changes are committed too
+	if _, err := pathspecs(tree, args[2:]); err != nil {
+		return unsupported(append([]string{"commit"}, args...))
+	}
+	opts := &git.CommitOptions{}
+	if name, email := os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL"); name != "" && email != "" {
+		opts.Author = &object.Signature{Name: name, Email: email, When: time.Now()}
+	}
+	if name, email := os.Getenv("GIT_COMMITTER_NAME"), os.Getenv("GIT_COMMITTER_EMAIL"); name != "" && email != "" {
+		opts.Committer = &object.Signature{Name: name, Email: email, When: time.Now()}
+	}
+	_, err = tree.Commit(args[1], opts)
+	return err
+}
+
+func (c *GoGitRunner) reset(repo *git.Repository, args []string) error {
+	if len(args) != 2 || args[0] != "--soft" {
+		return unsupported(append([]string{"reset"}, args...))
+	}
+	commit, err := resolveCommit(repo, args[1])
+	if err != nil {
+		return err
+	}
+	tree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return tree.Reset(&git.ResetOptions{Commit: commit.Hash, Mode: git.SoftReset})
+}
+
+func (c *GoGitRunner) log(repo *git.Repository, stdout io.Writer, args []string) error {
+	if len(args) != 2 || args[0] != "--format=%H%x1f%B%x1e" {
+		return unsupported(append([]string{"log"}, args...))
+	}
+	since, until := "", args[1]
+	if i := strings.Index(until, ".."); i >= 0 {
+		since, until = until[:i], until[i+2:]
+	}
+	exclude := map[plumbing.Hash]bool{}
+	if since != "" {
+		from, err := resolveCommit(repo, since)
+		if err != nil {
+			return err
+		}
+		iter, err := repo.Log(&git.LogOptions{From: from.Hash})
+		if err != nil {
+			return err
+		}
+		if err := iter.ForEach(func(commit *object.Commit) error {
+			exclude[commit.Hash] = true
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	to, err := resolveCommit(repo, until)
+	if err != nil {
+		return err
+	}
+	iter, err := repo.Log(&git.LogOptions{From: to.Hash})
+	if err != nil {
+		return err
+	}
+	return iter.ForEach(func(commit *object.Commit) error {
+		if exclude[commit.Hash] {
+			return nil
+		}
+		_, err := fmt.Fprintf(stdout, "%s\x1f%s\x1e\n", commit.Hash, commit.Message)
+		return err
+	})
+}
+
+func (c *GoGitRunner) revParse(repo *git.Repository, stdout io.Writer, args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "--show-toplevel":
+		tree, err := repo.Worktree()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, tree.Filesystem.Root())
+		return err
+	case len(args) == 3 && args[0] == "--verify" && args[1] == "--quiet" && strings.HasSuffix(args[2], "^{commit}"):
+		commit, err := resolveCommit(repo, strings.TrimSuffix(args[2], "^{commit}"))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, commit.Hash)
+		return err
+	case len(args) == 3 && args[0] == "--verify" && args[1] == "--quiet" && strings.HasPrefix(args[2], "refs/"):
+		// ResolveRevision peels annotated tags
+		ref, err := repo.Reference(plumbing.ReferenceName(args[2]), true)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, ref.Hash())
+		return err
+	}
+	return unsupported(append([]string{"rev-parse"}, args...))
+}
+
+func (c *GoGitRunner) updateRef(repo *git.Repository, args []string) error {
+	if len(args) != 2 || !strings.HasPrefix(args[0], "refs/") || !plumbing.IsHash(args[1]) {
+		return unsupported(append([]string{"update-ref"}, args...))
+	}
+	return repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(args[0]), plumbing.NewHash(args[1])))
+}
+
+func (c *GoGitRunner) config(repo *git.Repository, stdout io.Writer, args []string) error {
+	if len(args) != 3 || args[0] != "--null" || args[1] != "--get-regexp" || args[2] != `^vertag\.` {
+		return unsupported(append([]string{"config"}, args...))
+	}
+	cfg, err := repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return err
+	}
+	for _, opt := range cfg.Raw.Section("vertag").Options {
+		if _, err := fmt.Fprintf(stdout, "vertag.%s\n%s\x00", strings.ToLower(opt.Key), opt.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *GoGitRunner) ident(repo *git.Repository, stdout io.Writer, args []string) error {
+	if len(args) != 1 || args[0] != "GIT_COMMITTER_IDENT" {
+		return unsupported(append([]string{"var"}, args...))
+	}
+	name, email := os.Getenv("GIT_COMMITTER_NAME"), os.Getenv("GIT_COMMITTER_EMAIL")
+	if name == "" || email == "" {
+		cfg, err := repo.ConfigScoped(config.SystemScope)
+		if err != nil {
+			return err
+		}
+		name, email = cfg.User.Name, cfg.User.Email
+	}
+	if name == "" || email == "" {
+		return errors.New("committer identity unknown")
+	}
+	_, err := fmt.Fprintf(stdout, "%s <%s> %d +0000\n", name, email, time.Now().Unix())
+	return err
+}
+
+var _ ContextRunner = (*GoGitRunner)(nil)
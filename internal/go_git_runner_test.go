@@ -0,0 +1,259 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoGitRunner(t *testing.T) {
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(0, 0)}
+	commit := func(t *testing.T, repo *git.Repository, msg string) plumbing.Hash {
+		t.Helper()
+		tree, err := repo.Worktree()
+		require.NoError(t, err)
+		hash, err := tree.Commit(msg, &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+		require.NoError(t, err)
+		return hash
+	}
+	tset := func(t *testing.T) (*git.Repository, *Manager) {
+		t.Helper()
+		t.Setenv("GIT_COMMITTER_NAME", signature.Name)
+		t.Setenv("GIT_COMMITTER_EMAIL", signature.Email)
+		repo, err := git.Init(memory.NewStorage(), memfs.New())
+		require.NoError(t, err)
+		commit(t, repo, "init")
+		return repo, &Manager{Prefix: "v", Tagger: Tagger{Runner: &GoGitRunner{Repository: repo}}}
+	}
+
+	t.Run("create and get", func(t *testing.T) {
+		_, man := tset(t)
+		ver, err := man.GetVer()
+		require.NoError(t, err)
+		assert.Equal(t, "v0.0.0", ver)
+
+		res, err := man.UpdateMinor(nil, nil, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, "v0.0.0", res.Previous)
+		assert.Equal(t, "v0.1.0", res.Next)
+
+		ver, err = man.GetVer()
+		require.NoError(t, err)
+		assert.Equal(t, "v0.1.0", ver)
+	})
+
+	t.Run("annotated tag", func(t *testing.T) {
+		repo, man := tset(t)
+		require.NoError(t, man.createVer(SemVer{Major: 1}, nil, []string{"foo", "bar"}, ""))
+		ref, err := repo.Tag("v1.0.0")
+		require.NoError(t, err)
+		tag, err := repo.TagObject(ref.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, "foo\n\nbar\n", tag.Message)
+		assert.Equal(t, signature.Email, tag.Tagger.Email)
+	})
+
+	t.Run("points at", func(t *testing.T) {
+		repo, man := tset(t)
+		require.NoError(t, man.createVer(SemVer{Major: 1}, nil, []string{"annotated"}, ""))
+		commit(t, repo, "next")
+		require.NoError(t, man.createVer(SemVer{Major: 2}, nil, []string{"annotated"}, ""))
+		require.NoError(t, man.Tagger.CreateTag("foo", nil, ""))
+
+		tags, err := man.Tagger.GetTagsAtHead()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"foo", "v2.0.0"}, tags)
+	})
+
+	t.Run("merged", func(t *testing.T) {
+		repo, man := tset(t)
+		require.NoError(t, man.createVer(SemVer{Major: 1}, nil, nil, ""))
+		first, err := repo.Head()
+		require.NoError(t, err)
+		commit(t, repo, "next")
+		require.NoError(t, man.createVer(SemVer{Major: 2}, nil, []string{"annotated"}, ""))
+
+		man.Tagger.Merged = first.Hash().String()
+		ver, err := man.GetVer()
+		require.NoError(t, err)
+		assert.Equal(t, "v1.0.0", ver)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, man := tset(t)
+		require.NoError(t, man.createVer(SemVer{Major: 1}, nil, nil, ""))
+		require.NoError(t, man.createVer(SemVer{Major: 2}, nil, nil, ""))
+		res, err := man.DeleteVer()
+		require.NoError(t, err)
+		assert.Equal(t, "v2.0.0", res.Previous)
+		assert.Equal(t, "v1.0.0", res.Next)
+	})
+
+	t.Run("log", func(t *testing.T) {
+		repo, man := tset(t)
+		require.NoError(t, man.createVer(SemVer{Major: 1}, nil, nil, ""))
+		commit(t, repo, "feat: foo")
+		commit(t, repo, "fix: bar")
+		bump, err := man.DecideBump(DefaultBumpRules())
+		require.NoError(t, err)
+		assert.Equal(t, BumpMinor, bump)
+	})
+
+	t.Run("push and fetch with a file path remote", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := git.PlainInit(dir, true)
+		require.NoError(t, err)
+
+		_, man := tset(t)
+		man.Tagger.PushTo = dir
+		res, err := man.UpdatePatch(nil, nil, []string{"released"}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "v0.0.1", res.Next)
+
+		remote, err := git.PlainOpen(dir)
+		require.NoError(t, err)
+		_, err = remote.Tag("v0.0.1")
+		assert.NoError(t, err)
+
+		other, err := git.Init(memory.NewStorage(), memfs.New())
+		require.NoError(t, err)
+		_, err = other.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{dir}})
+		require.NoError(t, err)
+		tagger := Tagger{Runner: &GoGitRunner{Repository: other}}
+		tags, err := tagger.GetTags(true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v0.0.1"}, tags)
+
+		require.NoError(t, man.Tagger.DeleteTag("v0.0.1"))
+		_, err = remote.Tag("v0.0.1")
+		assert.ErrorIs(t, err, git.ErrTagNotFound)
+	})
+
+	t.Run("move ancestors in a file path remote", func(t *testing.T) {
+		dir := t.TempDir()
+		remote, err := git.PlainInit(dir, true)
+		require.NoError(t, err)
+
+		repo, man := tset(t)
+		man.Ancestors = true
+		man.Tagger.PushTo = dir
+		res, err := man.UpdatePatch(nil, nil, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v0.0.1", "v0", "v0.0"}, res.Created)
+		assert.Empty(t, res.Moved)
+
+		head := commit(t, repo, "next")
+		res, err = man.UpdatePatch(nil, nil, []string{"annotated"}, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v0.0.2", "v0", "v0.0"}, res.Created)
+		assert.Equal(t, []string{"v0", "v0.0"}, res.Moved)
+		for _, name := range []string{"v0", "v0.0"} {
+			ref, err := remote.Tag(name)
+			require.NoError(t, err)
+			target, err := peel(remote, ref.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, head, target, name)
+		}
+
+		// the remote tag is changed after checking it
+		remotes, err := man.Tagger.GetRemoteTags(dir, "v0.0")
+		require.NoError(t, err)
+		require.Len(t, remotes, 1)
+		other := commit(t, repo, "other")
+		require.NoError(t, remote.DeleteTag("v0.0"))
+		_, err = remote.CreateTag("v0.0", other, nil)
+		require.NoError(t, err)
+		assert.Error(t, man.Tagger.run(true, nil, "push", "--force-with-lease=refs/tags/v0.0:"+remotes[0].Object, dir, "refs/tags/v0.0"), "lease is stale")
+	})
+
+	t.Run("commit version files and push", func(t *testing.T) {
+		t.Setenv("GIT_AUTHOR_NAME", signature.Name)
+		t.Setenv("GIT_AUTHOR_EMAIL", signature.Email)
+		remoteDir := t.TempDir()
+		_, err := git.PlainInit(remoteDir, true)
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		repo, err := git.PlainInit(dir, false)
+		require.NoError(t, err)
+		name := filepath.Join(dir, "package.json")
+		require.NoError(t, os.WriteFile(name, []byte(`{"version": "0.0.0"}`), 0644))
+		tree, err := repo.Worktree()
+		require.NoError(t, err)
+		_, err = tree.Add("package.json")
+		require.NoError(t, err)
+		commit(t, repo, "init")
+
+		man := &Manager{
+			Prefix: "v",
+			Tagger: Tagger{Runner: &GoGitRunner{Repository: repo}, PushTo: remoteDir},
+			Files:  []VersionFile{{Type: VersionFileJSON, Path: name}},
+		}
+		_, err = man.UpdateMinor(nil, nil, nil, "")
+		require.NoError(t, err)
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+		headCommit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, "chore(release): v0.1.0", headCommit.Message)
+		tags, err := man.Tagger.GetTagsAtHead()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v0.1.0"}, tags)
+
+		remote, err := git.PlainOpen(remoteDir)
+		require.NoError(t, err)
+		ref, err := remote.Reference(head.Name(), false)
+		require.NoError(t, err)
+		assert.Equal(t, head.Hash(), ref.Hash())
+	})
+
+	t.Run("retry when another run took the version", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := git.PlainInit(dir, true)
+		require.NoError(t, err)
+		clone := func(t *testing.T) (*git.Repository, *Manager) {
+			repo, man := tset(t)
+			_, err := repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{dir}})
+			require.NoError(t, err)
+			man.Tagger.PushTo = git.DefaultRemoteName
+			return repo, man
+		}
+
+		_, first := clone(t)
+		_, err = first.UpdatePatch(nil, nil, nil, "")
+		require.NoError(t, err)
+
+		repo, second := clone(t)
+		commit(t, repo, "other")
+		_, err = second.UpdatePatch(nil, nil, nil, "")
+		assert.ErrorIs(t, err, ErrTagExists)
+		tags, err := second.Tagger.GetTags(false)
+		require.NoError(t, err)
+		assert.Empty(t, tags, "the local tag is rolled back")
+
+		second.Retry = 1
+		res, err := second.UpdatePatch(nil, nil, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, "v0.0.1", res.Previous)
+		assert.Equal(t, "v0.0.2", res.Next)
+		assert.Equal(t, 1, res.Retries)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, man := tset(t)
+		err := man.Tagger.run(true, &bytes.Buffer{}, "gc")
+		assert.ErrorIs(t, err, ErrUnsupportedCommand)
+	})
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const (
+	HookPreTag     = "pre-tag"
+	HookPostTag    = "post-tag"
+	HookPreDelete  = "pre-delete"
+	HookPostDelete = "post-delete"
+)
+
+// HookDir is the directory of hook scripts from the root of the repository.
+var HookDir = filepath.Join(".git-vertag", "hooks")
+
+var ErrHookFailed = errors.New("hook failed")
+
+// Hooks runs scripts around creating and deleting tags.
+// A pre-hook which fails aborts the operation, and a post-hook which fails is reported as a warning.
+type Hooks struct {
+	// Dir is the directory of executable scripts named after the hook (e.g. "pre-tag").
+	Dir string
+	// Commands are shell commands for each hook. They are run before the script in the Dir.
+	Commands map[string][]string
+	// Workdir is the directory where hooks run.
+	Workdir string
+	// DryRun shows hooks instead of running them.
+	DryRun bool
+	// Stdout is the output of hooks: os.Stderr is used if it is nil (to keep the stdout for the result).
+	Stdout io.Writer
+}
+
+// HookEnv is passed to hooks as environment variables.
+type HookEnv struct {
+	// Kind is the kind of the operation: "major", "minor", "patch", "pre", "release", "build", "post", "dev" or "delete".
+	Kind string
+	// Previous is the version tag before the operation.
+	Previous string
+	// PreviousVersion is the Previous without the prefix.
+	PreviousVersion string
+	// Next is the version tag after the operation.
+	Next string
+	// NextVersion is the Next without the prefix.
+	NextVersion string
+	// Remote is the remote repository to push.
+	Remote string
+}
+
+func (e HookEnv) environ(name string) []string {
+	return append(os.Environ(),
+		"GIT_VERTAG_HOOK="+name,
+		"GIT_VERTAG_KIND="+e.Kind,
+		"GIT_VERTAG_PREVIOUS="+e.Previous,
+		"GIT_VERTAG_PREVIOUS_VERSION="+e.PreviousVersion,
+		"GIT_VERTAG_NEXT="+e.Next,
+		"GIT_VERTAG_NEXT_VERSION="+e.NextVersion,
+		"GIT_VERTAG_REMOTE="+e.Remote,
+	)
+}
+
+func (h *Hooks) stdout() io.Writer {
+	if h.Stdout == nil {
+		return os.Stderr
+	}
+	return h.Stdout
+}
+
+func (h *Hooks) Run(name string, env HookEnv) error {
+	commands := h.Commands[name]
+	if h.Dir != "" {
+		script := filepath.Join(h.Dir, name)
+		// skip scripts without the exec bit, as git does
+		if stat, err := os.Stat(script); err == nil && !stat.IsDir() && stat.Mode()&0111 != 0 {
+			commands = append(commands[:len(commands):len(commands)], script)
+		}
+	}
+	for i, command := range commands {
+		if h.DryRun {
+			if _, err := fmt.Fprintf(h.stdout(), "may run %s hook: %s\n", name, command); err != nil {
+				return err
+			}
+			continue
+		}
+		var cmd *exec.Cmd
+		if i < len(h.Commands[name]) {
+			cmd = exec.Command("sh", "-c", command)
+		} else {
+			cmd = exec.Command(command)
+		}
+		cmd.Dir = h.Workdir
+		cmd.Env = env.environ(name)
+		cmd.Stdout = h.stdout()
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrHookFailed, name, err)
+		}
+	}
+	return nil
+}
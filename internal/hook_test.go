@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	env := HookEnv{
+		Kind:            "minor",
+		Previous:        "v1.2.3",
+		PreviousVersion: "1.2.3",
+		Next:            "v1.3.0",
+		NextVersion:     "1.3.0",
+		Remote:          "origin",
+	}
+
+	t.Run("commands and script", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, HookPreTag), []byte("#!/bin/sh\necho script $GIT_VERTAG_HOOK $GIT_VERTAG_KIND\n"), 0755))
+		var buf bytes.Buffer
+		hooks := &Hooks{
+			Dir:      dir,
+			Commands: map[string][]string{HookPreTag: {"echo command $GIT_VERTAG_PREVIOUS $GIT_VERTAG_NEXT_VERSION $GIT_VERTAG_REMOTE"}},
+			Stdout:   &buf,
+		}
+		require.NoError(t, hooks.Run(HookPreTag, env))
+		assert.Equal(t, "command v1.2.3 1.3.0 origin\nscript pre-tag minor\n", buf.String())
+	})
+
+	t.Run("no hook", func(t *testing.T) {
+		var buf bytes.Buffer
+		hooks := &Hooks{Dir: t.TempDir(), Stdout: &buf}
+		require.NoError(t, hooks.Run(HookPostTag, env))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("script without exec bit", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, HookPostTag), []byte("#!/bin/sh\necho script\n"), 0644))
+		var buf bytes.Buffer
+		hooks := &Hooks{Dir: dir, Stdout: &buf}
+		require.NoError(t, hooks.Run(HookPostTag, env))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("workdir", func(t *testing.T) {
+		dir := t.TempDir()
+		var buf bytes.Buffer
+		hooks := &Hooks{Commands: map[string][]string{HookPostTag: {"pwd"}}, Workdir: dir, Stdout: &buf}
+		require.NoError(t, hooks.Run(HookPostTag, env))
+		want, err := filepath.EvalSymlinks(dir)
+		require.NoError(t, err)
+		got, err := filepath.EvalSymlinks(strings.TrimSpace(buf.String()))
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		var buf bytes.Buffer
+		hooks := &Hooks{Commands: map[string][]string{HookPreTag: {"exit 1", "echo not reached"}}, Stdout: &buf}
+		assert.ErrorIs(t, hooks.Run(HookPreTag, env), ErrHookFailed)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		var buf bytes.Buffer
+		hooks := &Hooks{Commands: map[string][]string{HookPreTag: {"exit 1"}}, DryRun: true, Stdout: &buf}
+		require.NoError(t, hooks.Run(HookPreTag, env))
+		assert.Equal(t, "may run pre-tag hook: exit 1\n", buf.String())
+	})
+}
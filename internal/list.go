@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/blang/semver/v4"
+)
+
+// ListOptions filters versions to list.
+type ListOptions struct {
+	// Range limits versions to ones in the range (e.g. ">=1.2.0 <2.0.0"). Nil means any versions.
+	Range semver.Range
+	// StableOnly excludes pre-release versions.
+	StableOnly bool
+	// PreOnly excludes versions other than pre-release ones.
+	PreOnly bool
+	// LatestPer keeps only the latest version for each "major", "minor" or "patch".
+	LatestPer string
+	// Limit keeps only the latest versions up to the number. Zero means no limit.
+	Limit int
+}
+
+func validLevel(level string) error {
+	switch level {
+	case "", "major", "minor", "patch":
+		return nil
+	}
+	return fmt.Errorf("invalid level %q: it should be major, minor or patch", level)
+}
+
+func sameLine(v, w Ver, level string) bool {
+	a, b := v.Semver(), w.Semver()
+	switch level {
+	case "major":
+		return a.Major == b.Major
+	case "minor":
+		return a.Major == b.Major && a.Minor == b.Minor
+	case "patch":
+		return a.Major == b.Major && a.Minor == b.Minor && a.Patch == b.Patch
+	}
+	return false
+}
+
+// List lists version tags filtered with the options in ascending order of the versions.
+func (m *Manager) List(opts ListOptions) ([]string, error) {
+	if err := validLevel(opts.LatestPer); err != nil {
+		return nil, err
+	}
+	vers, err := m.getVers()
+	if err != nil {
+		return nil, err
+	}
+	var filtered Vers
+	for _, v := range vers {
+		switch {
+		case opts.StableOnly && isPre(v):
+			continue
+		case opts.PreOnly && !isPre(v):
+			continue
+		case opts.Range != nil && !opts.Range(v.Semver()):
+			continue
+		}
+		if opts.LatestPer != "" && len(filtered) > 0 && sameLine(filtered[len(filtered)-1], v, opts.LatestPer) {
+			// vers is sorted: the later one is newer
+			filtered[len(filtered)-1] = v
+			continue
+		}
+		filtered = append(filtered, v)
+	}
+	if opts.Limit > 0 && len(filtered) > opts.Limit {
+		filtered = filtered[len(filtered)-opts.Limit:]
+	}
+	tags := make([]string, 0, len(filtered))
+	for _, v := range filtered {
+		tags = append(tags, m.tagName(v.String()))
+	}
+	return tags, nil
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestList(t *testing.T) {
+	const tags = "test1.10.0\ntest1.2.0\ntest1.2.1-rc.1\ntest1.2.1\nfoo\ntest2.0.0-alpha.1\ntest1.9.3\ntest0.1.0\ntest1.2.0-beta.2\n"
+	list := func(t *testing.T, opts ListOptions) []string {
+		t.Helper()
+		buf := &bytes.Buffer{}
+		man := &Manager{Prefix: "test", Tagger: Tagger{Runner: &MockRunner{echo: buf, output: strings.NewReader(tags)}}}
+		got, err := man.List(opts)
+		require.NoError(t, err)
+		assert.Equal(t, "git tag -l\n", buf.String())
+		return got
+	}
+
+	t.Run("all", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"test0.1.0", "test1.2.0-beta.2", "test1.2.0", "test1.2.1-rc.1", "test1.2.1",
+			"test1.9.3", "test1.10.0", "test2.0.0-alpha.1",
+		}, list(t, ListOptions{}))
+	})
+	t.Run("range", func(t *testing.T) {
+		r, err := semver.ParseRange(">=1.2.0 <2.0.0")
+		require.NoError(t, err)
+		// pre-releases of 2.0.0 precede it
+		assert.Equal(t, []string{"test1.2.0", "test1.2.1-rc.1", "test1.2.1", "test1.9.3", "test1.10.0", "test2.0.0-alpha.1"}, list(t, ListOptions{Range: r}))
+		r, err = semver.ParseRange(">=1.2.0 <2.0.0-0")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test1.2.0", "test1.2.1-rc.1", "test1.2.1", "test1.9.3", "test1.10.0"}, list(t, ListOptions{Range: r}))
+	})
+	t.Run("stable only", func(t *testing.T) {
+		assert.Equal(t, []string{"test0.1.0", "test1.2.0", "test1.2.1", "test1.9.3", "test1.10.0"}, list(t, ListOptions{StableOnly: true}))
+	})
+	t.Run("pre only", func(t *testing.T) {
+		assert.Equal(t, []string{"test1.2.0-beta.2", "test1.2.1-rc.1", "test2.0.0-alpha.1"}, list(t, ListOptions{PreOnly: true}))
+	})
+	t.Run("latest per minor", func(t *testing.T) {
+		assert.Equal(t, []string{"test0.1.0", "test1.2.1", "test1.9.3", "test1.10.0", "test2.0.0-alpha.1"}, list(t, ListOptions{LatestPer: "minor"}))
+	})
+	t.Run("latest stable per major", func(t *testing.T) {
+		assert.Equal(t, []string{"test0.1.0", "test1.10.0"}, list(t, ListOptions{LatestPer: "major", StableOnly: true}))
+	})
+	t.Run("limit", func(t *testing.T) {
+		assert.Equal(t, []string{"test1.10.0", "test2.0.0-alpha.1"}, list(t, ListOptions{Limit: 2}))
+	})
+	t.Run("invalid level", func(t *testing.T) {
+		man := &Manager{Prefix: "test", Tagger: Tagger{Runner: &MockRunner{echo: &bytes.Buffer{}}}}
+		_, err := man.List(ListOptions{LatestPer: "build"})
+		assert.Error(t, err)
+	})
+}
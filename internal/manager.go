@@ -3,7 +3,7 @@ package internal
 import (
 	"errors"
 	"fmt"
-	"strconv"
+	"sort"
 	"strings"
 
 	"github.com/blang/semver/v4"
@@ -14,59 +14,189 @@ type Manager struct {
 	Tagger    Tagger
 	Fetch     bool
 	Ancestors bool
+	// Suffix is put after the version in tag names (e.g. "-final" for "v1.2.3-final").
+	Suffix string
+	// Scheme parses and bumps versions. Nil means the StrictSemVer.
+	Scheme Scheme
+	// Channels are the pre-release identifiers in order (e.g. "alpha", "beta" and "rc"). Nil means DefaultChannels (or a, b and rc for the PEP 440).
+	Channels []string
+	// Module is the Go module to be tagged. If it is set, versions are checked with the module path.
+	Module *GoModule
+	// RewriteModule rewrites the module path instead of failing when it does not match the major version.
+	RewriteModule bool
+	// Verify makes ValidateVer reject tags without a valid signature.
+	Verify bool
+	// MessageTemplate renders tag messages with MessageData by text/template, unless messages or a file are given.
+	MessageTemplate string
+	// Hooks runs scripts around creating and deleting tags.
+	Hooks *Hooks
+	// Files are rewritten with the next version and committed before tagging.
+	Files []VersionFile
+	// Retry is the number of times to fetch tags and tag again,
+	// when the push is rejected because the next version is tagged in the remote by another run.
+	Retry int
 }
 
-var ErrInvalidVer = errors.New("invalid vertag")
+var (
+	ErrInvalidVer = errors.New("invalid vertag")
+	ErrNoBump     = errors.New("no commits to bump the version")
+	ErrUnverified = errors.New("no valid signature")
+	ErrTagExists  = errors.New("tag already exists in the remote")
+	// ErrUnsupported is returned for updates which the version scheme does not have.
+	ErrUnsupported = errors.New("unsupported update")
+)
+
+type ancestor struct {
+	tag   string
+	level string
+}
 
-func (m *Manager) ancestors(v semver.Version) []string {
+// ancestors are named with the leading numbers of the version as it is (e.g. "1" and "1.2" for "1.2.3-rc.1").
+func (m *Manager) ancestors(v Ver) []ancestor {
 	if !m.Ancestors {
 		return nil
 	}
-	var ancs []string
-	b := make([]byte, 0, 3)
-	b = strconv.AppendUint(b, v.Major, 10)
-	ancs = append(ancs, m.Prefix+string(b))
+	return m.ancestorsOf(v)
+}
 
-	b = append(b, '.')
-	b = strconv.AppendUint(b, v.Minor, 10)
-	ancs = append(ancs, m.Prefix+string(b))
+func (m *Manager) ancestorsOf(v Ver) []ancestor {
+	release := v.String()
+	if i := strings.IndexFunc(release, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); i >= 0 {
+		release = release[:i]
+	}
+	fields := strings.Split(release, ".")
+	var ancs []ancestor
+	for i, level := range []string{"major", "minor"} {
+		if i+1 >= len(fields) {
+			// the version itself
+			break
+		}
+		ancs = append(ancs, ancestor{tag: m.tagName(strings.Join(fields[:i+1], ".")), level: level})
+	}
 	return ancs
 }
 
-func (m *Manager) deleteVer(v semver.Version) error {
-	if err := m.Tagger.DeleteTag(m.Prefix + v.String()); err != nil {
+func (m *Manager) deleteVer(v Ver) (*TagUpdate, error) {
+	return m.Tagger.RemoveTag(m.tagName(v.String()))
+}
+
+// checkModule verifies the module path only when the major version goes up,
+// so that bumps in a major version keep working with the module path as it is.
+func (m *Manager) checkModule(cur, next Ver) error {
+	if m.Module == nil {
+		return nil
+	}
+	major := next.Semver().Major
+	if major <= cur.Semver().Major {
+		return nil
+	}
+	err := m.Module.CheckMajor(major)
+	if err == nil || !m.RewriteModule {
 		return err
 	}
-	return nil
+	oldPath := m.Module.Path
+	if err := m.Module.RewriteMajor(major); err != nil {
+		return fmt.Errorf("failed to rewrite module path: %w", err)
+	}
+	return fmt.Errorf("%w from %s to %s: commit the changes and tag again", ErrModuleRewrote, oldPath, m.Module.Path)
+}
+
+func (m *Manager) runHook(name, kind string, cur, next Ver) error {
+	if m.Hooks == nil {
+		return nil
+	}
+	return m.Hooks.Run(name, HookEnv{
+		Kind:            kind,
+		Previous:        m.tagName(cur.String()),
+		PreviousVersion: cur.String(),
+		Next:            m.tagName(next.String()),
+		NextVersion:     next.String(),
+		Remote:          m.Tagger.PushTo,
+	})
 }
 
-func (m *Manager) createVer(v semver.Version, msg []string, file string) error {
-	if err := m.Tagger.CreateTag(m.Prefix+v.String(), msg, file); err != nil {
+// writeFiles syncs the version in the Files, and commits them to be tagged.
+// It returns true if a commit is created.
+func (m *Manager) writeFiles(v Ver) (bool, error) {
+	var changed []string
+	for _, f := range m.Files {
+		ok, err := f.Write(v.String())
+		if err != nil {
+			return false, fmt.Errorf("failed to write the version: %w", err)
+		}
+		if ok {
+			changed = append(changed, f.Path)
+		}
+	}
+	if len(changed) == 0 {
+		return false, nil
+	}
+	if err := m.Tagger.CommitFiles("chore(release): "+m.tagName(v.String()), changed); err != nil {
+		return false, fmt.Errorf("failed to commit the version: %w", err)
+	}
+	return true, nil
+}
+
+func (m *Manager) createVer(v Ver, data *MessageData, msg []string, file string) error {
+	msg, err := m.messages(data, msg, file)
+	if err != nil {
+		return err
+	}
+	if err := m.Tagger.CreateTag(m.tagName(v.String()), msg, file); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (m *Manager) getVer() (semver.Version, error) {
-	var latest semver.Version
+// getVers lists versions of the tags in ascending order.
+func (m *Manager) getVers() (Vers, error) {
 	tags, err := m.Tagger.GetTags(m.Fetch)
 	if err != nil {
-		return latest, err
+		return nil, err
 	}
+	return m.parseVers(tags), nil
+}
+
+// parseVers picks versions from the tags in ascending order.
+// Ancestors of other versions (e.g. "v1" and "v1.2" of "v1.2.3") are skipped,
+// since schemes with short release numbers (e.g. the PEP 440) read them as versions.
+func (m *Manager) parseVers(tags []string) Vers {
+	ancestors := m.ancestorTags(tags)
+	var vers Vers
 	for _, tag := range tags {
-		if !strings.HasPrefix(tag, m.Prefix) {
+		if ancestors[tag] {
 			continue
 		}
-		tag = strings.TrimPrefix(tag, m.Prefix)
-		ver, err := semver.Parse(tag)
-		if err != nil {
-			continue
+		if ver, ok := m.parseTag(tag); ok {
+			vers = append(vers, ver)
 		}
-		if latest.Compare(ver) < 0 {
-			latest = ver
+	}
+	sort.Sort(vers)
+	return vers
+}
+
+// ancestorTags collects the ancestor tags of the versions in the tags, even if the Ancestors is disabled.
+func (m *Manager) ancestorTags(tags []string) map[string]bool {
+	ancestors := map[string]bool{}
+	for _, tag := range tags {
+		if ver, ok := m.parseTag(tag); ok {
+			for _, anc := range m.ancestorsOf(ver) {
+				ancestors[anc.tag] = true
+			}
 		}
 	}
+	return ancestors
+}
 
+func (m *Manager) getVer() (Ver, error) {
+	latest := m.zeroVer()
+	vers, err := m.getVers()
+	if err != nil {
+		return latest, err
+	}
+	if len(vers) > 0 {
+		latest = vers[len(vers)-1]
+	}
 	return latest, nil
 }
 
@@ -75,121 +205,376 @@ func (m *Manager) GetVer() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return m.Prefix + v.String(), nil
+	return m.tagName(v.String()), nil
+}
+
+// tagName builds the tag name of the version (or the ancestor like "1.2").
+func (m *Manager) tagName(version string) string {
+	return m.Prefix + version + m.Suffix
+}
+
+// parseTag parses the version in the tag name.
+func (m *Manager) parseTag(tag string) (Ver, bool) {
+	if len(tag) < len(m.Prefix)+len(m.Suffix) || !strings.HasPrefix(tag, m.Prefix) || !strings.HasSuffix(tag, m.Suffix) {
+		return nil, false
+	}
+	return m.scheme().Parse(tag[len(m.Prefix) : len(tag)-len(m.Suffix)])
+}
+
+func (m *Manager) scheme() Scheme {
+	if m.Scheme == nil {
+		return StrictSemVer{}
+	}
+	return m.Scheme
+}
+
+// zeroVer is the version before the first one, which is used without version tags.
+func (m *Manager) zeroVer() Ver {
+	return m.scheme().Zero()
+}
+
+// nextVer computes the next version of the kind with the scheme.
+func (m *Manager) nextVer(cur Ver, kind string, pre []semver.PRVersion, build []string) (Ver, error) {
+	return m.scheme().Bump(cur, Change{Kind: kind, Pre: pre, Build: build, Date: now()})
 }
 
 func (m *Manager) validVer(tag string) bool {
-	if !strings.HasPrefix(tag, m.Prefix) {
-		return false
+	_, ok := m.parseTag(tag)
+	return ok
+}
+
+func (m *Manager) verifyVer(tag string) error {
+	if !m.Verify {
+		return nil
+	}
+	if err := m.Tagger.VerifyTag(tag); err != nil {
+		return fmt.Errorf("%w: %s", ErrUnverified, tag)
 	}
-	_, err := semver.Parse(strings.TrimPrefix(tag, m.Prefix))
-	return err == nil
+	return nil
 }
 
 func (m *Manager) ValidateVer(tag string) (string, error) {
 	if tag != "" {
-		if m.validVer(tag) {
-			return tag, nil
+		if !m.validVer(tag) {
+			return "", fmt.Errorf("%w: %s", ErrInvalidVer, tag)
+		}
+		if err := m.verifyVer(tag); err != nil {
+			return "", err
 		}
-		return "", fmt.Errorf("%w: %s", ErrInvalidVer, tag)
+		return tag, nil
 	}
-
 	tags, err := m.Tagger.GetTagsAtHead()
 	if err != nil {
 		return "", err
 	}
+	ancestors := m.ancestorTags(tags)
+	verr := ErrInvalidVer
 	for _, tag := range tags {
-		if m.validVer(tag) {
-			return tag, nil
+		if ancestors[tag] || !m.validVer(tag) {
+			continue
+		}
+		if err := m.verifyVer(tag); err != nil {
+			verr = err
+			continue
 		}
+		return tag, nil
 	}
-	return "", ErrInvalidVer
+	return "", verr
 }
 
-func (m *Manager) DeleteVer() (string, error) {
-	{
-		vold, err := m.getVer()
-		if err != nil {
-			return "", fmt.Errorf("failed to get current ver: %w", err)
-		}
-		if err := m.deleteVer(vold); err != nil {
-			return "", fmt.Errorf("failed to delete ver: %w", err)
-		}
+// DeleteVer deletes the current version tag.
+func (m *Manager) DeleteVer() (*Result, error) {
+	plan, err := m.PlanDelete(DeleteOptions{})
+	if err != nil {
+		return nil, err
 	}
-	{
-		vnew, err := m.getVer()
-		if err != nil {
-			return "", fmt.Errorf("failed to refetch new ver: %w", err)
-		}
-		return m.Prefix + vnew.String(), nil
+	return m.Delete(plan, nil, "")
+}
+
+// newResult builds the result of creating the tag of the next version.
+func (m *Manager) newResult(cur, next Ver) *Result {
+	return &Result{
+		Previous: m.tagName(cur.String()),
+		Next:     m.tagName(next.String()),
+		Version:  NewVersionInfo(next.Semver()),
+		Remote:   m.Tagger.PushTo,
+		Created:  []string{m.tagName(next.String())},
 	}
 }
 
-func (m *Manager) UpdateMajor(pre []semver.PRVersion, build, msg []string, file string) (string, string, error) {
-	return m.update(pre, build, msg, file, func(u Updater) UpdatePre { return u.Major() })
+func (m *Manager) UpdateMajor(pre []semver.PRVersion, build, msg []string, file string) (*Result, error) {
+	return m.update("major", pre, build, msg, file)
+}
+
+func (m *Manager) UpdateMinor(pre []semver.PRVersion, build, msg []string, file string) (*Result, error) {
+	return m.update("minor", pre, build, msg, file)
+}
+
+func (m *Manager) UpdatePatch(pre []semver.PRVersion, build, msg []string, file string) (*Result, error) {
+	return m.update("patch", pre, build, msg, file)
+}
+
+func (m *Manager) UpdatePre(pre []semver.PRVersion, build, msg []string, file string) (*Result, error) {
+	return m.update("pre", pre, build, msg, file)
 }
 
-func (m *Manager) UpdateMinor(pre []semver.PRVersion, build, msg []string, file string) (string, string, error) {
-	return m.update(pre, build, msg, file, func(u Updater) UpdatePre { return u.Minor() })
+// UpdatePost creates a tag for the next post-release of the PEP 440 (e.g. "1.2.0.post1").
+func (m *Manager) UpdatePost(msg []string, file string) (*Result, error) {
+	return m.update("post", nil, nil, msg, file)
 }
 
-func (m *Manager) UpdatePatch(pre []semver.PRVersion, build, msg []string, file string) (string, string, error) {
-	return m.update(pre, build, msg, file, func(u Updater) UpdatePre { return u.Patch() })
+// UpdateDev creates a tag for the next development release of the PEP 440 (e.g. "1.2.1.dev1").
+func (m *Manager) UpdateDev(msg []string, file string) (*Result, error) {
+	return m.update("dev", nil, nil, msg, file)
 }
 
-func (m *Manager) UpdatePre(pre []semver.PRVersion, build, msg []string, file string) (string, string, error) {
-	return m.update(pre, build, msg, file, func(u Updater) UpdatePre { return u })
+func (m *Manager) update(kind string, pre []semver.PRVersion, build, msg []string, file string) (*Result, error) {
+	return m.retry(func() (*Result, error) {
+		cur, err := m.getVer()
+		if err != nil {
+			return nil, err
+		}
+		return m.updateVer(cur, kind, pre, build, msg, file)
+	})
 }
 
-func (m *Manager) update(
+// retry calls the tagging function again with the fetched tags while the next version is taken in the remote.
+func (m *Manager) retry(tagging func() (*Result, error)) (*Result, error) {
+	for i := 0; ; i++ {
+		res, err := tagging()
+		if err == nil {
+			res.Retries = i
+			return res, nil
+		}
+		if !errors.Is(err, ErrTagExists) || i >= m.Retry {
+			return nil, err
+		}
+		if err := m.Tagger.FetchTags(); err != nil {
+			return nil, fmt.Errorf("failed to fetch tags to retry: %w", err)
+		}
+	}
+}
+
+func (m *Manager) updateVer(
+	cur Ver,
+	kind string,
 	pre []semver.PRVersion,
 	build,
 	msg []string,
 	file string,
-	upd func(Updater) UpdatePre,
-) (string, string, error) {
-	cur, err := m.getVer()
+) (*Result, error) {
+	if kind == "pre" && len(pre) > 0 {
+		if err := m.checkChannel(cur, pre); err != nil {
+			return nil, err
+		}
+	}
+	next, err := m.nextVer(cur, kind, pre, build)
+	if err != nil {
+		return nil, err
+	}
+	data, err := m.newMessageData(cur, next, kind)
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+	return m.tagVer(cur, next, kind, data, msg, file, m.ancestors(next))
+}
+
+// tagVer creates the tags of the next version and the ancestors, and pushes them at once.
+// If it fails, the tags created or moved in the local are rolled back.
+func (m *Manager) tagVer(
+	cur,
+	next Ver,
+	kind string,
+	data *MessageData,
+	msg []string,
+	file string,
+	ancestors []ancestor,
+) (*Result, error) {
+	if err := m.runHook(HookPreTag, kind, cur, next); err != nil {
+		return nil, err
+	}
+	if err := m.checkModule(cur, next); err != nil {
+		return nil, err
 	}
-	next, err := upd(NewUpdater(cur)).Pre(pre...).Build(build...).Version()
+	committed, err := m.writeFiles(next)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
-	if err := m.createVer(next, msg, file); err != nil {
-		return "", "", err
+	if err := m.createVer(next, data, msg, file); err != nil {
+		if committed {
+			return nil, m.rollback(err, committed, nil)
+		}
+		return nil, err
 	}
-	for _, anc := range m.ancestors(next) {
-		if err := m.Tagger.DeleteTag(anc); err != nil {
-			// noop
-			_ = err
+	res := m.newResult(cur, next)
+	updates := []*TagUpdate{{Tag: m.tagName(next.String())}}
+	for _, anc := range ancestors {
+		if data != nil {
+			data.Tag, data.Ancestor = anc.tag, anc.level
+		}
+		ancMsg, err := m.messages(data, msg, file)
+		if err != nil {
+			return nil, m.rollback(err, committed, updates)
+		}
+		update, err := m.Tagger.MoveTag(anc.tag, ancMsg, file)
+		if err != nil {
+			return nil, m.rollback(fmt.Errorf("failed to move %s: %w", anc.tag, err), committed, updates)
 		}
-		if err := m.Tagger.CreateTag(anc, msg, file); err != nil {
-			return "", "", err
+		updates = append(updates, update)
+		res.Created = append(res.Created, anc.tag)
+		if update.Moved {
+			res.Moved = append(res.Moved, anc.tag)
 		}
 	}
-	return m.Prefix + cur.String(), m.Prefix + next.String(), nil
+	if err := m.Tagger.PushTags(committed, updates...); err != nil {
+		err = fmt.Errorf("failed to push tags: %w", err)
+		if m.Tagger.PushTo != "" {
+			// another run may have pushed the same version first
+			if remotes, rerr := m.Tagger.GetRemoteTags(m.Tagger.PushTo, updates[0].Tag); rerr == nil && len(remotes) > 0 {
+				err = fmt.Errorf("%w: %s in %s", ErrTagExists, updates[0].Tag, m.Tagger.PushTo)
+			}
+		}
+		return nil, m.rollback(err, committed, updates)
+	}
+	if err := m.runHook(HookPostTag, kind, cur, next); err != nil {
+		// the tags are already pushed
+		res.Warnings = append(res.Warnings, err.Error())
+	}
+	return res, nil
 }
 
-func (m *Manager) Release(build, msg []string, file string) (string, string, error) {
-	return m.release(build, msg, file, func(u Updater) UpdateBuild { return u.Release() })
+// rollback restores the tags and the commit of the version files in the local, and returns the error with the result.
+func (m *Manager) rollback(err error, committed bool, updates []*TagUpdate) error {
+	if rerr := m.Tagger.RollbackTags(updates...); rerr != nil {
+		return fmt.Errorf("%w (failed to roll back tags: %s)", err, rerr)
+	}
+	if committed {
+		if rerr := m.Tagger.ResetCommit(); rerr != nil {
+			return fmt.Errorf("%w (failed to roll back the commit: %s)", err, rerr)
+		}
+	}
+	return fmt.Errorf("%w (tags are rolled back)", err)
+}
+
+func (m *Manager) Release(build, msg []string, file string) (*Result, error) {
+	return m.release("release", build, msg, file)
 }
 
-func (m *Manager) Build(build, msg []string, file string) (string, string, error) {
-	return m.release(build, msg, file, func(u Updater) UpdateBuild { return u })
+func (m *Manager) Build(build, msg []string, file string) (*Result, error) {
+	return m.release("build", build, msg, file)
 }
 
-func (m *Manager) release(build, msg []string, file string, upd func(Updater) UpdateBuild) (string, string, error) {
+func (m *Manager) release(kind string, build, msg []string, file string) (*Result, error) {
+	return m.retry(func() (*Result, error) {
+		cur, err := m.getVer()
+		if err != nil {
+			return nil, err
+		}
+		next, err := m.nextVer(cur, kind, nil, build)
+		if err != nil {
+			return nil, err
+		}
+		data, err := m.newMessageData(cur, next, kind)
+		if err != nil {
+			return nil, err
+		}
+		return m.tagVer(cur, next, kind, data, msg, file, nil)
+	})
+}
+
+// DecideBump classifies commits since the current version tag with the rules.
+func (m *Manager) DecideBump(rules BumpRules) (Bump, error) {
 	cur, err := m.getVer()
 	if err != nil {
-		return "", "", err
+		return BumpNone, err
+	}
+	return m.decideBump(cur, rules)
+}
+
+// commitsSince lists commits since the version tag; all commits for the zero version.
+func (m *Manager) commitsSince(cur Ver) ([]Commit, error) {
+	revRange := "HEAD"
+	if cur.Compare(m.zeroVer()) != 0 {
+		revRange = m.tagName(cur.String()) + "..HEAD"
 	}
-	next, err := upd(NewUpdater(cur)).Build(build...).Version()
+	commits, err := m.Tagger.GetCommits(revRange)
 	if err != nil {
-		return "", "", err
+		return nil, fmt.Errorf("failed to get commits: %w", err)
 	}
-	if err = m.createVer(next, msg, file); err != nil {
-		return "", "", err
+	return commits, nil
+}
+
+func (m *Manager) decideBump(cur Ver, rules BumpRules) (Bump, error) {
+	commits, err := m.commitsSince(cur)
+	if err != nil {
+		return BumpNone, err
+	}
+	return rules.Decide(commits), nil
+}
+
+func (m *Manager) Auto(rules BumpRules, pre []semver.PRVersion, build, msg []string, file string) (*Result, error) {
+	return m.retry(func() (*Result, error) {
+		return m.auto(rules, pre, build, msg, file)
+	})
+}
+
+func (m *Manager) auto(rules BumpRules, pre []semver.PRVersion, build, msg []string, file string) (*Result, error) {
+	cur, err := m.getVer()
+	if err != nil {
+		return nil, err
+	}
+	bump, err := m.decideBump(cur, rules)
+	if err != nil {
+		return nil, err
+	}
+	if bump == BumpNone {
+		return nil, ErrNoBump
+	}
+	return m.updateVer(cur, bump.String(), pre, build, msg, file)
+}
+
+// Changelog collects commits from the tag "from" to the tag "to".
+// If "to" is empty, the current version tag is used.
+// If "from" is empty, the previous version of "to" is used: pre-releases are skipped for a release version.
+func (m *Manager) Changelog(from, to string) (*Changelog, error) {
+	vers, err := m.getVers()
+	if err != nil {
+		return nil, err
+	}
+	if to == "" {
+		if len(vers) == 0 {
+			to = "HEAD"
+		} else {
+			to = m.tagName(vers[len(vers)-1].String())
+		}
+	}
+	if from == "" {
+		toVer, isVer := m.parseTag(to)
+		for i := len(vers) - 1; i >= 0; i-- {
+			v := vers[i]
+			if isVer && (v.Compare(toVer) >= 0 || (!isPre(toVer) && isPre(v))) {
+				continue
+			}
+			from = m.tagName(v.String())
+			break
+		}
+	}
+	revRange := to
+	if from != "" {
+		revRange = from + ".." + to
+	}
+	commits, err := m.Tagger.GetCommits(revRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits: %w", err)
+	}
+	return NewChangelog(from, to, commits), nil
+}
+
+// Describe builds the result for the tag.
+func (m *Manager) Describe(tag string) *Result {
+	res := &Result{Tag: tag}
+	if v, ok := m.parseTag(tag); ok {
+		res.Version = NewVersionInfo(v.Semver())
 	}
-	return m.Prefix + cur.String(), m.Prefix + next.String(), nil
+	return res
 }
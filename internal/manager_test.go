@@ -2,12 +2,17 @@ package internal
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestManager(t *testing.T) {
@@ -20,7 +25,7 @@ func TestManager(t *testing.T) {
 
 	t.Run("create ver", func(t *testing.T) {
 		buf, _, man := tset()
-		assert.NoError(t, man.createVer(semver.Version{Major: 1, Minor: 2, Patch: 3}, nil, ""))
+		assert.NoError(t, man.createVer(SemVer{Major: 1, Minor: 2, Patch: 3}, nil, nil, ""))
 		assert.Equal(t, "git tag test1.2.3\n", buf.String())
 	})
 
@@ -81,6 +86,39 @@ func TestManager(t *testing.T) {
 			assert.Equal(t, "git tag --points-at HEAD\n", buf.String())
 		})
 
+		t.Run("verify", func(t *testing.T) {
+			buf, _, man := tset()
+			man.Verify = true
+			ver, err := man.ValidateVer("test1.2.3")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.2.3", ver)
+			assert.Equal(t, "git tag --verify test1.2.3\n", buf.String())
+		})
+		t.Run("reject unsigned", func(t *testing.T) {
+			_, run, man := tset()
+			run.errs = map[string]error{"tag --verify test1.2.3": errors.New("exit status 1")}
+			man.Verify = true
+			_, err := man.ValidateVer("test1.2.3")
+			assert.ErrorIs(t, err, ErrUnverified)
+		})
+		t.Run("verify tags at head", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\ntest1.2.4\n")
+			run.errs = map[string]error{"tag --verify test1.2.3": errors.New("exit status 1")}
+			man.Verify = true
+			ver, err := man.ValidateVer("")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.2.4", ver)
+			assert.Equal(t, "git tag --points-at HEAD\ngit tag --verify test1.2.3\ngit tag --verify test1.2.4\n", buf.String())
+		})
+		t.Run("reject unsigned tags at head", func(t *testing.T) {
+			_, run, man := tset()
+			run.output = strings.NewReader("foo\ntest1.2.3\n")
+			run.errs = map[string]error{"tag --verify test1.2.3": errors.New("exit status 1")}
+			man.Verify = true
+			_, err := man.ValidateVer("")
+			assert.ErrorIs(t, err, ErrUnverified)
+		})
 		t.Run("without valid tag at head", func(t *testing.T) {
 			buf, run, man := tset()
 			run.output = strings.NewReader("foo\ntest1.2\n")
@@ -94,94 +132,611 @@ func TestManager(t *testing.T) {
 		t.Run("build", func(t *testing.T) {
 			buf, run, man := tset()
 			run.output = strings.NewReader("test1.2.3-pre-release.4+build-ver.5\n")
-			cur, next, err := man.Build(
+			res, err := man.Build(
 				[]string{"test-bld", "2"},
 				[]string{"test-msg"},
 				"")
 			assert.NoError(t, err)
-			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", cur)
-			assert.Equal(t, "test1.2.3-pre-release.4+test-bld.2", next)
+			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", res.Previous)
+			assert.Equal(t, "test1.2.3-pre-release.4+test-bld.2", res.Next)
 			assert.Equal(t, "git tag -l\ngit tag --message test-msg test1.2.3-pre-release.4+test-bld.2\n", buf.String())
 		})
 		t.Run("release", func(t *testing.T) {
 			buf, run, man := tset()
 			run.output = strings.NewReader("test1.2.3-pre-release.4+build-ver.5\n")
-			cur, next, err := man.Release(
+			res, err := man.Release(
 				[]string{"test-bld", "2"},
 				[]string{"test-msg"},
 				"")
 			assert.NoError(t, err)
-			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", cur)
-			assert.Equal(t, "test1.2.3+test-bld.2", next)
+			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", res.Previous)
+			assert.Equal(t, "test1.2.3+test-bld.2", res.Next)
 			assert.Equal(t, "git tag -l\ngit tag --message test-msg test1.2.3+test-bld.2\n", buf.String())
 		})
 		t.Run("set pre-release", func(t *testing.T) {
 			buf, run, man := tset()
 			run.output = strings.NewReader("test1.2.3-pre-release.4+build-ver.5\n")
-			cur, next, err := man.UpdatePre(
+			res, err := man.UpdatePre(
 				[]semver.PRVersion{mustPRVer(t, "test-pre"), mustPRVer(t, "1")},
 				[]string{"test-bld", "2"},
 				[]string{"test-msg"},
 				"")
 			assert.NoError(t, err)
-			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", cur)
-			assert.Equal(t, "test1.2.3-test-pre.1+test-bld.2", next)
+			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", res.Previous)
+			assert.Equal(t, "test1.2.3-test-pre.1+test-bld.2", res.Next)
 			assert.Equal(t, "git tag -l\ngit tag --message test-msg test1.2.3-test-pre.1+test-bld.2\n", buf.String())
 		})
 		t.Run("increment pre-release", func(t *testing.T) {
 			buf, run, man := tset()
 			run.output = strings.NewReader("test1.2.3-pre-release.4+build-ver.5\n")
-			cur, next, err := man.UpdatePre(
+			res, err := man.UpdatePre(
 				nil,
 				[]string{"test-bld", "2"},
 				[]string{"test-msg"},
 				"")
 			assert.NoError(t, err)
-			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", cur)
-			assert.Equal(t, "test1.2.3-pre-release.5+test-bld.2", next)
+			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", res.Previous)
+			assert.Equal(t, "test1.2.3-pre-release.5+test-bld.2", res.Next)
 			assert.Equal(t, "git tag -l\ngit tag --message test-msg test1.2.3-pre-release.5+test-bld.2\n", buf.String())
 		})
 		t.Run("increment patch", func(t *testing.T) {
 			buf, run, man := tset()
 			run.output = strings.NewReader("test1.2.3-pre-release.4+build-ver.5\n")
-			cur, next, err := man.UpdatePatch(
+			res, err := man.UpdatePatch(
 				[]semver.PRVersion{mustPRVer(t, "test-pre"), mustPRVer(t, "1")},
 				[]string{"test-bld", "2"},
 				[]string{"test-msg"},
 				"")
 			assert.NoError(t, err)
-			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", cur)
-			assert.Equal(t, "test1.2.4-test-pre.1+test-bld.2", next)
+			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", res.Previous)
+			assert.Equal(t, "test1.2.4-test-pre.1+test-bld.2", res.Next)
 			assert.Equal(t, "git tag -l\ngit tag --message test-msg test1.2.4-test-pre.1+test-bld.2\n", buf.String())
 		})
 		t.Run("increment minor", func(t *testing.T) {
 			buf, run, man := tset()
 			run.output = strings.NewReader("test1.2.3-pre-release.4+build-ver.5\n")
-			cur, next, err := man.UpdateMinor(
+			res, err := man.UpdateMinor(
 				[]semver.PRVersion{mustPRVer(t, "test-pre"), mustPRVer(t, "1")},
 				[]string{"test-bld", "2"},
 				[]string{"test-msg"},
 				"")
 			assert.NoError(t, err)
-			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", cur)
-			assert.Equal(t, "test1.3.0-test-pre.1+test-bld.2", next)
+			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", res.Previous)
+			assert.Equal(t, "test1.3.0-test-pre.1+test-bld.2", res.Next)
 			assert.Equal(t, "git tag -l\ngit tag --message test-msg test1.3.0-test-pre.1+test-bld.2\n", buf.String())
 		})
 		t.Run("increment major", func(t *testing.T) {
 			buf, run, man := tset()
 			run.output = strings.NewReader("test1.2.3-pre-release.4+build-ver.5\n")
-			cur, next, err := man.UpdateMajor(
+			res, err := man.UpdateMajor(
 				[]semver.PRVersion{mustPRVer(t, "test-pre"), mustPRVer(t, "1")},
 				[]string{"test-bld", "2"},
 				[]string{"test-msg"},
 				"")
 			assert.NoError(t, err)
-			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", cur)
-			assert.Equal(t, "test2.0.0-test-pre.1+test-bld.2", next)
+			assert.Equal(t, "test1.2.3-pre-release.4+build-ver.5", res.Previous)
+			assert.Equal(t, "test2.0.0-test-pre.1+test-bld.2", res.Next)
 			assert.Equal(t, "git tag -l\ngit tag --message test-msg test2.0.0-test-pre.1+test-bld.2\n", buf.String())
 		})
 	})
 
+	t.Run("auto", func(t *testing.T) {
+		t.Run("decide", func(t *testing.T) {
+			buf, run, man := tset()
+			run.outputs = map[string]io.Reader{
+				"tag -l": strings.NewReader("test1.2.3\n"),
+				"log --format=%H%x1f%B%x1e test1.2.3..HEAD": strings.NewReader("a\x1ffix: foo\x1e\nb\x1ffeat: bar\x1e\n"),
+			}
+			bump, err := man.DecideBump(DefaultBumpRules())
+			assert.NoError(t, err)
+			assert.Equal(t, BumpMinor, bump)
+			assert.Equal(t, "git tag -l\ngit log --format=%H%x1f%B%x1e test1.2.3..HEAD\n", buf.String())
+		})
+		t.Run("decide without tag", func(t *testing.T) {
+			buf, _, man := tset()
+			bump, err := man.DecideBump(DefaultBumpRules())
+			assert.NoError(t, err)
+			assert.Equal(t, BumpNone, bump)
+			assert.Equal(t, "git tag -l\ngit log --format=%H%x1f%B%x1e HEAD\n", buf.String())
+		})
+		t.Run("update", func(t *testing.T) {
+			buf, run, man := tset()
+			run.outputs = map[string]io.Reader{
+				"tag -l": strings.NewReader("test1.2.3\n"),
+				"log --format=%H%x1f%B%x1e test1.2.3..HEAD": strings.NewReader("a\x1ffix!: foo\x1e\n"),
+			}
+			res, err := man.Auto(DefaultBumpRules(), nil, nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.2.3", res.Previous)
+			assert.Equal(t, "test2.0.0", res.Next)
+			assert.Equal(t, "git tag -l\ngit log --format=%H%x1f%B%x1e test1.2.3..HEAD\ngit tag test2.0.0\n", buf.String())
+		})
+		t.Run("nothing to bump", func(t *testing.T) {
+			_, run, man := tset()
+			run.outputs = map[string]io.Reader{
+				"tag -l": strings.NewReader("test1.2.3\n"),
+				"log --format=%H%x1f%B%x1e test1.2.3..HEAD": strings.NewReader("a\x1fdocs: foo\x1e\n"),
+			}
+			_, err := man.Auto(DefaultBumpRules(), nil, nil, nil, "")
+			assert.ErrorIs(t, err, ErrNoBump)
+		})
+	})
+
+	t.Run("changelog", func(t *testing.T) {
+		tags := "test1.2.0\ntest1.3.0-rc.1\ntest1.3.0\ntest1.2.1\ntest1.4.0-rc.1\n"
+		t.Run("release", func(t *testing.T) {
+			buf, run, man := tset()
+			run.outputs = map[string]io.Reader{"tag -l": strings.NewReader(tags)}
+			log, err := man.Changelog("", "test1.3.0")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.2.1", log.From)
+			assert.Equal(t, "test1.3.0", log.To)
+			assert.Equal(t, "git tag -l\ngit log --format=%H%x1f%B%x1e test1.2.1..test1.3.0\n", buf.String())
+		})
+		t.Run("pre-release", func(t *testing.T) {
+			_, run, man := tset()
+			run.outputs = map[string]io.Reader{"tag -l": strings.NewReader(tags)}
+			log, err := man.Changelog("", "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.3.0", log.From)
+			assert.Equal(t, "test1.4.0-rc.1", log.To)
+		})
+		t.Run("explicit range", func(t *testing.T) {
+			buf, run, man := tset()
+			run.outputs = map[string]io.Reader{"tag -l": strings.NewReader(tags)}
+			_, err := man.Changelog("test1.2.0", "HEAD")
+			assert.NoError(t, err)
+			assert.Equal(t, "git tag -l\ngit log --format=%H%x1f%B%x1e test1.2.0..HEAD\n", buf.String())
+		})
+		t.Run("without tag", func(t *testing.T) {
+			buf, _, man := tset()
+			log, err := man.Changelog("", "")
+			assert.NoError(t, err)
+			assert.Equal(t, "", log.From)
+			assert.Equal(t, "HEAD", log.To)
+			assert.Equal(t, "git tag -l\ngit log --format=%H%x1f%B%x1e HEAD\n", buf.String())
+		})
+	})
+
+	t.Run("module major guard", func(t *testing.T) {
+		t.Run("reject", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			man.Module = &GoModule{Path: "example.com/foo"}
+			_, err := man.UpdateMajor(nil, nil, nil, "")
+			assert.ErrorIs(t, err, ErrModuleMajor)
+			assert.Equal(t, "git tag -l\n", buf.String())
+		})
+		t.Run("accept", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			man.Module = &GoModule{Path: "example.com/foo/v2"}
+			res, err := man.UpdateMajor(nil, nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test2.0.0", res.Next)
+			assert.Equal(t, "git tag -l\ngit tag test2.0.0\n", buf.String())
+		})
+		t.Run("bump in the major version", func(t *testing.T) {
+			for _, update := range []func(*Manager) (*Result, error){
+				func(m *Manager) (*Result, error) { return m.UpdatePatch(nil, nil, nil, "") },
+				func(m *Manager) (*Result, error) { return m.UpdateMinor(nil, nil, nil, "") },
+			} {
+				_, run, man := tset()
+				run.output = strings.NewReader("test2.3.0\n")
+				man.Module = &GoModule{Path: "example.com/foo"}
+				_, err := update(man)
+				assert.NoError(t, err)
+			}
+		})
+		t.Run("rewrite", func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/foo\n"), 0644))
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			man.Module = &GoModule{Dir: dir, Path: "example.com/foo"}
+			man.RewriteModule = true
+			_, err := man.UpdateMajor(nil, nil, nil, "")
+			assert.ErrorIs(t, err, ErrModuleRewrote)
+			assert.Equal(t, "example.com/foo/v2", man.Module.Path)
+			assert.Equal(t, "git tag -l\n", buf.String())
+		})
+	})
+
+	t.Run("result", func(t *testing.T) {
+		t.Run("with ancestors", func(t *testing.T) {
+			buf, run, man := tset()
+			run.outputs = map[string]io.Reader{
+				"tag -l": strings.NewReader("test1.2.3\n"),
+				"rev-parse --verify --quiet HEAD^{commit}":                   strings.NewReader("head\n"),
+				"rev-parse --verify --quiet refs/tags/test1^{commit}":        strings.NewReader("old\n"),
+				"ls-remote --tags origin refs/tags/test1 refs/tags/test1^{}": strings.NewReader("obj\trefs/tags/test1\nold\trefs/tags/test1^{}\n"),
+			}
+			run.errs = map[string]error{
+				"rev-parse --verify --quiet refs/tags/test1.3^{commit}": errors.New("not found"),
+			}
+			man.Ancestors = true
+			man.Tagger.PushTo = "origin"
+			res, err := man.UpdateMinor(nil, nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, &Result{
+				Previous: "test1.2.3",
+				Next:     "test1.3.0",
+				Version:  &VersionInfo{Major: 1, Minor: 3},
+				Remote:   "origin",
+				Created:  []string{"test1.3.0", "test1", "test1.3"},
+				Moved:    []string{"test1"},
+			}, res)
+			assert.Equal(t, "git tag -l\n"+
+				"git tag test1.3.0\n"+
+				"git rev-parse --verify --quiet HEAD^{commit}\n"+
+				"git rev-parse --verify --quiet refs/tags/test1\n"+
+				"git rev-parse --verify --quiet refs/tags/test1^{commit}\n"+
+				"git ls-remote --tags origin refs/tags/test1 refs/tags/test1^{}\n"+
+				"git tag --force test1\n"+
+				"git rev-parse --verify --quiet HEAD^{commit}\n"+
+				"git rev-parse --verify --quiet refs/tags/test1.3\n"+
+				"git rev-parse --verify --quiet refs/tags/test1.3^{commit}\n"+
+				"git ls-remote --tags origin refs/tags/test1.3 refs/tags/test1.3^{}\n"+
+				"git tag --force test1.3\n"+
+				"git push --atomic --force-with-lease=refs/tags/test1:obj --force-with-lease=refs/tags/test1.3: origin refs/tags/test1.3.0 refs/tags/test1 refs/tags/test1.3\n", buf.String())
+		})
+		t.Run("rollback on failure to push", func(t *testing.T) {
+			buf, run, man := tset()
+			run.outputs = map[string]io.Reader{
+				"tag -l": strings.NewReader("test1.2.3\n"),
+				"rev-parse --verify --quiet HEAD^{commit}":            strings.NewReader("head\n"),
+				"rev-parse --verify --quiet refs/tags/test1":          strings.NewReader("obj\n"),
+				"rev-parse --verify --quiet refs/tags/test1^{commit}": strings.NewReader("old\n"),
+			}
+			run.errs = map[string]error{
+				"rev-parse --verify --quiet refs/tags/test1.3":          errors.New("exit status 1"),
+				"rev-parse --verify --quiet refs/tags/test1.3^{commit}": errors.New("exit status 1"),
+				"push --atomic --force-with-lease=refs/tags/test1: --force-with-lease=refs/tags/test1.3: origin refs/tags/test1.3.0 refs/tags/test1 refs/tags/test1.3": errors.New("stale info"),
+			}
+			man.Ancestors = true
+			man.Tagger.PushTo = "origin"
+			_, err := man.UpdateMinor(nil, nil, nil, "")
+			assert.ErrorContains(t, err, "stale info")
+			assert.ErrorContains(t, err, "rolled back")
+			assert.True(t, strings.HasSuffix(buf.String(), "git tag -d test1.3\ngit update-ref refs/tags/test1 obj\ngit tag -d test1.3.0\n"), buf.String())
+		})
+		t.Run("rollback on failure to move ancestor", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			run.errs = map[string]error{
+				"tag --force test1": errors.New("exit status 1"),
+			}
+			man.Ancestors = true
+			_, err := man.UpdateMinor(nil, nil, nil, "")
+			assert.Error(t, err)
+			assert.True(t, strings.HasSuffix(buf.String(), "git tag --force test1\ngit tag -d test1.3.0\n"), buf.String())
+			assert.NotContains(t, buf.String(), "git push")
+		})
+		t.Run("describe", func(t *testing.T) {
+			_, _, man := tset()
+			assert.Equal(t, &Result{
+				Tag:     "test1.2.3-pre.1+build.2",
+				Version: &VersionInfo{Major: 1, Minor: 2, Patch: 3, Pre: []string{"pre", "1"}, Build: []string{"build", "2"}},
+			}, man.Describe("test1.2.3-pre.1+build.2"))
+			assert.Equal(t, &Result{Tag: "foo"}, man.Describe("foo"))
+		})
+	})
+
+	t.Run("write files", func(t *testing.T) {
+		t.Run("commit and tag", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			name := filepath.Join(t.TempDir(), "package.json")
+			require.NoError(t, os.WriteFile(name, []byte(`{"version": "1.2.3"}`), 0644))
+			man.Files = []VersionFile{{Type: VersionFileJSON, Path: name}}
+			man.Tagger.PushTo = "origin"
+			_, err := man.UpdatePatch(nil, nil, nil, "")
+			require.NoError(t, err)
+			data, err := os.ReadFile(name)
+			require.NoError(t, err)
+			assert.Equal(t, `{"version": "1.2.4"}`, string(data))
+			assert.Equal(t, "git tag -l\ngit add -- "+name+"\ngit commit --message \"chore(release): test1.2.4\" -- "+name+"\ngit tag test1.2.4\ngit push --atomic origin HEAD refs/tags/test1.2.4\n", buf.String())
+		})
+		t.Run("roll back the commit", func(t *testing.T) {
+			buf, run, man := tset()
+			run.outputs = map[string]io.Reader{
+				"tag -l": strings.NewReader("test1.2.3\n"),
+				"ls-remote --tags origin refs/tags/test1.2.4 refs/tags/test1.2.4^{}": strings.NewReader("obj\trefs/tags/test1.2.4\n"),
+			}
+			run.errs = map[string]error{
+				"push --atomic origin HEAD refs/tags/test1.2.4": errors.New("rejected"),
+			}
+			name := filepath.Join(t.TempDir(), "package.json")
+			require.NoError(t, os.WriteFile(name, []byte(`{"version": "1.2.3"}`), 0644))
+			man.Files = []VersionFile{{Type: VersionFileJSON, Path: name}}
+			man.Tagger.PushTo = "origin"
+			_, err := man.UpdatePatch(nil, nil, nil, "")
+			assert.ErrorIs(t, err, ErrTagExists)
+			assert.True(t, strings.HasSuffix(buf.String(), "git tag -d test1.2.4\ngit reset --soft HEAD~1\n"), buf.String())
+		})
+		t.Run("unchanged", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			name := filepath.Join(t.TempDir(), "package.json")
+			require.NoError(t, os.WriteFile(name, []byte(`{"version": "1.2.4"}`), 0644))
+			man.Files = []VersionFile{{Type: VersionFileJSON, Path: name}}
+			_, err := man.UpdatePatch(nil, nil, nil, "")
+			require.NoError(t, err)
+			assert.Equal(t, "git tag -l\ngit tag test1.2.4\n", buf.String())
+		})
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		tset := func() (*bytes.Buffer, *MockRunner, *Manager) {
+			buf, run, man := tset()
+			man.Prefix, man.Suffix = "v", "-final"
+			return buf, run, man
+		}
+		t.Run("get ver", func(t *testing.T) {
+			_, run, man := tset()
+			run.output = strings.NewReader("v1.2.3-final\nv2.0.0\nv1.3.0-rc.1-final\nv1.2-final\n")
+			ver, err := man.GetVer()
+			assert.NoError(t, err)
+			assert.Equal(t, "v1.3.0-rc.1-final", ver)
+		})
+		t.Run("validate ver", func(t *testing.T) {
+			_, _, man := tset()
+			ver, err := man.ValidateVer("v1.2.3-rc.1-final")
+			assert.NoError(t, err)
+			assert.Equal(t, "v1.2.3-rc.1-final", ver)
+			_, err = man.ValidateVer("v1.2.3")
+			assert.ErrorIs(t, err, ErrInvalidVer)
+		})
+		t.Run("update with ancestors", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("v1.2.3-final\n")
+			man.Ancestors = true
+			res, err := man.UpdatePatch(nil, nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "v1.2.4-final", res.Next)
+			assert.Equal(t, []string{"v1.2.4-final", "v1-final", "v1.2-final"}, res.Created)
+			assert.Contains(t, buf.String(), "git tag v1.2.4-final\n")
+			assert.Contains(t, buf.String(), "git tag --force v1-final\n")
+			assert.Contains(t, buf.String(), "git tag --force v1.2-final\n")
+		})
+	})
+
+	t.Run("calver", func(t *testing.T) {
+		defer func(orig func() time.Time) { now = orig }(now)
+		now = func() time.Time { return time.Date(2024, 5, 17, 3, 4, 5, 0, time.UTC) }
+		tset := func() (*bytes.Buffer, *MockRunner, *Manager) {
+			buf, run, man := tset()
+			cal, err := ParseCalVer("YYYY.0M.MICRO")
+			require.NoError(t, err)
+			man.Scheme = cal
+			return buf, run, man
+		}
+		t.Run("get ver", func(t *testing.T) {
+			_, run, man := tset()
+			run.output = strings.NewReader("test2024.04.3\ntest2024.05.1\ntest3.0.0\ntest2024.5.2\n")
+			ver, err := man.GetVer()
+			assert.NoError(t, err)
+			assert.Equal(t, "test2024.05.1", ver)
+		})
+		t.Run("update with ancestors", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test2024.05.1\n")
+			man.Ancestors = true
+			res, err := man.UpdatePatch(nil, nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test2024.05.1", res.Previous)
+			assert.Equal(t, "test2024.05.2", res.Next)
+			assert.Equal(t, []string{"test2024.05.2", "test2024", "test2024.05"}, res.Created)
+			assert.Contains(t, buf.String(), "git tag test2024.05.2\n")
+		})
+		t.Run("new month", func(t *testing.T) {
+			_, run, man := tset()
+			run.output = strings.NewReader("test2024.04.3\n")
+			res, err := man.UpdateMinor(nil, nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test2024.05.0", res.Next)
+		})
+		t.Run("reject pre-release", func(t *testing.T) {
+			_, run, man := tset()
+			run.output = strings.NewReader("test2024.05.1\n")
+			_, err := man.UpdatePatch([]semver.PRVersion{{VersionStr: "rc"}}, nil, nil, "")
+			assert.ErrorIs(t, err, ErrUnsupported)
+			_, _, man = tset()
+			_, err = man.Release(nil, nil, "")
+			assert.ErrorIs(t, err, ErrUnsupported)
+		})
+	})
+
+	t.Run("scheme", func(t *testing.T) {
+		t.Run("get ver", func(t *testing.T) {
+			_, run, man := tset()
+			man.Scheme = PEP440{}
+			run.output = strings.NewReader("test1.2.0\ntest1.3.0rc1\ntest1.3.0-rc.2\ntest1.3.0-foo.3\ntest1.3.0b4\n")
+			ver, err := man.GetVer()
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.3.0-rc.2", ver, "the tag is as it is")
+		})
+		t.Run("update", func(t *testing.T) {
+			buf, run, man := tset()
+			man.Scheme = TolerantSemVer{}
+			run.output = strings.NewReader("test1.2\ntest1.10\ntest1.9.1\n")
+			res, err := man.UpdateMinor(nil, nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.10", res.Previous)
+			assert.Equal(t, "test1.11", res.Next)
+			assert.Equal(t, &VersionInfo{Major: 1, Minor: 11}, res.Version)
+			assert.Contains(t, buf.String(), "git tag test1.11\n")
+		})
+		t.Run("skip ancestors", func(t *testing.T) {
+			_, run, man := tset()
+			man.Scheme = PEP440{}
+			run.output = strings.NewReader("test1\ntest1.2\ntest1.2.0rc1\n")
+			ver, err := man.GetVer()
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.2.0rc1", ver)
+
+			buf, run, man := tset()
+			man.Scheme = TolerantSemVer{}
+			man.Ancestors = true
+			run.output = strings.NewReader("test1\ntest1.2\ntest1.2.0-rc.1\n")
+			res, err := man.Release(nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.2.0-rc.1", res.Previous)
+			assert.Equal(t, "test1.2.0", res.Next)
+			assert.Contains(t, buf.String(), "git tag test1.2.0\n")
+
+			_, run, man = tset()
+			man.Scheme = PEP440{}
+			run.output = strings.NewReader("test1\ntest1.2\ntest1.2.0\n")
+			ver, err = man.ValidateVer("")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.2.0", ver, "the ancestors at the head are not the version")
+		})
+		t.Run("post and dev", func(t *testing.T) {
+			buf, run, man := tset()
+			man.Scheme = PEP440{}
+			run.output = strings.NewReader("test1.2.0\ntest1.2.0rc1\n")
+			res, err := man.UpdatePost(nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.2.0.post1", res.Next)
+			assert.Contains(t, buf.String(), "git tag test1.2.0.post1\n")
+
+			buf, run, man = tset()
+			man.Scheme = PEP440{}
+			run.output = strings.NewReader("test1.2.0\n")
+			res, err = man.UpdateDev(nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.2.1.dev1", res.Next)
+			assert.Contains(t, buf.String(), "git tag test1.2.1.dev1\n")
+
+			_, run, man = tset()
+			run.output = strings.NewReader("test1.2.0\n")
+			_, err = man.UpdatePost(nil, "")
+			assert.ErrorIs(t, err, ErrUnsupported)
+		})
+	})
+
+	t.Run("promote", func(t *testing.T) {
+		t.Run("next channel", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\ntest1.3.0-alpha.4\n")
+			res, err := man.Promote(nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.3.0-beta.1", res.Next)
+			assert.Contains(t, buf.String(), "git tag test1.3.0-beta.1\n")
+		})
+		t.Run("configured channels", func(t *testing.T) {
+			_, run, man := tset()
+			man.Channels = []string{"dev", "preview", "rc"}
+			run.output = strings.NewReader("test1.3.0-preview.2\n")
+			res, err := man.Promote(nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.3.0-rc.1", res.Next)
+		})
+		t.Run("pep440", func(t *testing.T) {
+			_, run, man := tset()
+			man.Scheme = PEP440{}
+			run.output = strings.NewReader("test1.3.0a4\n")
+			res, err := man.Promote(nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "test1.3.0b1", res.Next)
+		})
+		t.Run("last channel", func(t *testing.T) {
+			_, run, man := tset()
+			run.output = strings.NewReader("test1.3.0-rc.3\n")
+			_, err := man.Promote(nil, nil, "")
+			assert.ErrorContains(t, err, "last channel rc")
+		})
+		t.Run("not in channels", func(t *testing.T) {
+			_, run, man := tset()
+			run.output = strings.NewReader("test1.3.0-foo.3\n")
+			_, err := man.Promote(nil, nil, "")
+			assert.ErrorContains(t, err, "not in the channels alpha, beta, rc")
+		})
+		t.Run("reject earlier channel", func(t *testing.T) {
+			buf, run, man := tset()
+			man.Channels = []string{"snapshot", "beta"}
+			run.output = strings.NewReader("test1.3.0-beta.2\n")
+			_, err := man.UpdatePre([]semver.PRVersion{mustPRVer(t, "snapshot"), mustPRVer(t, "1")}, nil, nil, "")
+			assert.ErrorContains(t, err, "snapshot is before beta in the channels snapshot, beta")
+			assert.Equal(t, "git tag -l\n", buf.String())
+		})
+		t.Run("reject rewinding number", func(t *testing.T) {
+			_, run, man := tset()
+			run.output = strings.NewReader("test1.3.0-rc.3\n")
+			_, err := man.UpdatePre([]semver.PRVersion{mustPRVer(t, "rc"), mustPRVer(t, "1")}, nil, nil, "")
+			assert.ErrorContains(t, err, "rewinds version order")
+		})
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		hooks := func(buf *bytes.Buffer, commands map[string][]string) *Hooks {
+			return &Hooks{Commands: commands, Stdout: buf}
+		}
+		t.Run("around update", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			man.Hooks = hooks(buf, map[string][]string{
+				HookPreTag:  {"echo pre $GIT_VERTAG_KIND $GIT_VERTAG_PREVIOUS $GIT_VERTAG_NEXT"},
+				HookPostTag: {"echo post $GIT_VERTAG_PREVIOUS_VERSION $GIT_VERTAG_NEXT_VERSION"},
+			})
+			_, err := man.UpdateMinor(nil, nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "git tag -l\npre minor test1.2.3 test1.3.0\ngit tag test1.3.0\npost 1.2.3 1.3.0\n", buf.String())
+		})
+		t.Run("around release", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3-rc.1\n")
+			man.Hooks = hooks(buf, map[string][]string{
+				HookPreTag:  {"echo pre $GIT_VERTAG_KIND $GIT_VERTAG_NEXT"},
+				HookPostTag: {"echo post $GIT_VERTAG_KIND $GIT_VERTAG_NEXT"},
+			})
+			_, err := man.Release(nil, nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "git tag -l\npre release test1.2.3\ngit tag test1.2.3\npost release test1.2.3\n", buf.String())
+		})
+		t.Run("pre-tag aborts", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			man.Hooks = hooks(buf, map[string][]string{
+				HookPreTag:  {"exit 1"},
+				HookPostTag: {"echo post"},
+			})
+			_, err := man.UpdatePatch(nil, nil, nil, "")
+			assert.ErrorIs(t, err, ErrHookFailed)
+			assert.Equal(t, "git tag -l\n", buf.String())
+		})
+		t.Run("post-tag fails", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			man.Hooks = hooks(buf, map[string][]string{HookPostTag: {"exit 1"}})
+			res, err := man.UpdatePatch(nil, nil, nil, "")
+			require.NoError(t, err)
+			assert.Equal(t, "test1.2.4", res.Next)
+			require.Len(t, res.Warnings, 1)
+			assert.Contains(t, res.Warnings[0], "post-tag")
+			assert.Equal(t, "git tag -l\ngit tag test1.2.4\n", buf.String())
+		})
+		t.Run("around delete", func(t *testing.T) {
+			buf, run, man := tset()
+			run.outputs = map[string]io.Reader{"tag -l": strings.NewReader("test1.2.3\ntest1.3.0\n")}
+			man.Hooks = hooks(buf, map[string][]string{
+				HookPreDelete:  {"echo pre $GIT_VERTAG_KIND $GIT_VERTAG_PREVIOUS $GIT_VERTAG_NEXT"},
+				HookPostDelete: {"echo post $GIT_VERTAG_PREVIOUS"},
+			})
+			_, err := man.DeleteVer()
+			assert.NoError(t, err)
+			assert.Equal(t, "git tag -l\npre delete test1.3.0 test1.2.3\ngit rev-parse --verify --quiet refs/tags/test1.3.0\ngit tag -d test1.3.0\npost test1.3.0\n", buf.String())
+		})
+		t.Run("pre-delete aborts", func(t *testing.T) {
+			buf, run, man := tset()
+			run.output = strings.NewReader("test1.2.3\n")
+			man.Hooks = hooks(buf, map[string][]string{HookPreDelete: {"exit 1"}})
+			_, err := man.DeleteVer()
+			assert.ErrorIs(t, err, ErrHookFailed)
+			assert.Equal(t, "git tag -l\n", buf.String())
+		})
+	})
 }
 
 func TestManagerFS(t *testing.T) {
@@ -216,7 +771,7 @@ func TestManagerFS(t *testing.T) {
 		t.Run("create", func(t *testing.T) {
 			man, tear := init(t)
 			defer tear()
-			assert.NoError(t, man.createVer(semver.Version{Patch: 1}, nil, ""))
+			assert.NoError(t, man.createVer(SemVer{Patch: 1}, nil, nil, ""))
 			ver, err := man.GetVer()
 			assert.NoError(t, err)
 			assert.Equal(t, "0.0.1", ver)
@@ -233,7 +788,8 @@ func TestManagerFS(t *testing.T) {
 		t.Run("delete", func(t *testing.T) {
 			man, tear := init(t)
 			defer tear()
-			assert.Error(t, man.deleteVer(semver.Version{Patch: 1}))
+			_, err := man.deleteVer(SemVer{Patch: 1})
+			assert.Error(t, err)
 		})
 	})
 
@@ -241,7 +797,7 @@ func TestManagerFS(t *testing.T) {
 		t.Run("create", func(t *testing.T) {
 			man, tear := temp(t)
 			defer tear()
-			assert.Error(t, man.createVer(semver.Version{Major: 1}, nil, ""))
+			assert.Error(t, man.createVer(SemVer{Major: 1}, nil, nil, ""))
 		})
 
 		t.Run("get", func(t *testing.T) {
@@ -254,7 +810,28 @@ func TestManagerFS(t *testing.T) {
 		t.Run("delete", func(t *testing.T) {
 			man, tear := temp(t)
 			defer tear()
-			assert.Error(t, man.deleteVer(semver.Version{Major: 1}))
+			_, err := man.deleteVer(SemVer{Major: 1})
+			assert.Error(t, err)
 		})
 	})
+
+	t.Run("reachable", func(t *testing.T) {
+		man, tear := init(t)
+		defer tear()
+		man.Prefix = "v"
+		assert.NoError(t, man.createVer(SemVer{Major: 1, Minor: 4}, nil, nil, ""))
+		assert.NoError(t, man.Tagger.run(true, nil, "checkout", "-q", "-b", "main-line"))
+		assert.NoError(t, man.Tagger.run(true, nil, "commit", "--allow-empty", "-m", "next"))
+		assert.NoError(t, man.createVer(SemVer{Major: 2}, nil, nil, ""))
+		assert.NoError(t, man.Tagger.run(true, nil, "checkout", "-q", "v1.4.0"))
+
+		ver, err := man.GetVer()
+		assert.NoError(t, err)
+		assert.Equal(t, "v2.0.0", ver)
+
+		man.Tagger.Merged = "HEAD"
+		ver, err = man.GetVer()
+		assert.NoError(t, err)
+		assert.Equal(t, "v1.4.0", ver)
+	})
 }
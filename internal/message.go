@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// MessageData is the data to render the MessageTemplate of the Manager.
+type MessageData struct {
+	// Previous is the version tag before the update.
+	Previous string
+	// Next is the version tag which is created.
+	Next string
+	// Kind is the kind of the update: "major", "minor", "patch", "pre", "release", "build", "post" or "dev".
+	// It is "delete" for ancestors moved back by Delete, where Next is the version tag which they point at.
+	Kind string
+	// Shortlog is the commits since the previous version tag. It is empty for "delete".
+	Shortlog []Commit
+	// Author is the identity (e.g. "Name <name@example.com>") of the tagger.
+	Author string
+	Date   time.Time
+	// Tag is the tag which the message is for: Next, or an ancestor of it.
+	Tag string
+	// Ancestor is the level of the ancestor tag ("major" for vN or "minor" for vN.N), or empty for the version tag.
+	Ancestor string
+}
+
+var now = time.Now
+
+// newMessageData collects the data for the MessageTemplate. It returns nil without the template.
+func (m *Manager) newMessageData(cur, next Ver, kind string) (*MessageData, error) {
+	if m.MessageTemplate == "" {
+		return nil, nil
+	}
+	commits, err := m.commitsSince(cur)
+	if err != nil {
+		return nil, err
+	}
+	author, err := m.Tagger.GetIdent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the tagger: %w", err)
+	}
+	return &MessageData{
+		Previous: m.tagName(cur.String()),
+		Next:     m.tagName(next.String()),
+		Kind:     kind,
+		Shortlog: commits,
+		Author:   author,
+		Date:     now(),
+		Tag:      m.tagName(next.String()),
+	}, nil
+}
+
+// messages builds the messages for the tag: the template is used only if neither messages nor a file are given.
+func (m *Manager) messages(data *MessageData, msg []string, file string) ([]string, error) {
+	if data == nil || len(msg) > 0 || file != "" {
+		return msg, nil
+	}
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(m.MessageTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render message template: %w", err)
+	}
+	return []string{buf.String()}, nil
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	tset := func() (*bytes.Buffer, *MockRunner, *Manager) {
+		buffer := &bytes.Buffer{}
+		runner := &MockRunner{echo: buffer, outputs: map[string]io.Reader{
+			"tag -l":                                 strings.NewReader("v1.2.3\n"),
+			"log --format=%H%x1f%B%x1e v1.2.3..HEAD": strings.NewReader("a\x1ffix: foo\n\nbody\x1e\nb\x1ffeat: bar\x1e\n"),
+			"var GIT_COMMITTER_IDENT":                strings.NewReader("Name <name@example.com> 1704164645 +0000\n"),
+		}}
+		manager := &Manager{Prefix: "v", Tagger: Tagger{Runner: runner}}
+		return buffer, runner, manager
+	}
+
+	t.Run("render", func(t *testing.T) {
+		buf, _, man := tset()
+		man.MessageTemplate = `{{.Previous}}->{{.Next}} {{.Kind}} by {{.Author}} at {{.Date.Format "2006-01-02"}}{{range .Shortlog}};{{.Subject}}{{end}}`
+		_, err := man.UpdateMinor(nil, nil, nil, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "git tag -l\n"+
+			"git log --format=%H%x1f%B%x1e v1.2.3..HEAD\n"+
+			"git var GIT_COMMITTER_IDENT\n"+
+			"git tag --message \"v1.2.3->v1.3.0 minor by Name <name@example.com> at 2024-01-02;fix: foo;feat: bar\" v1.3.0\n", buf.String())
+	})
+	t.Run("ancestors", func(t *testing.T) {
+		buf, _, man := tset()
+		man.Ancestors = true
+		man.MessageTemplate = `{{.Tag}}:{{.Ancestor}}`
+		_, err := man.UpdatePatch(nil, nil, nil, "")
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "git tag --message v1.2.4: v1.2.4\n")
+		assert.Contains(t, buf.String(), "git tag --force --message v1:major v1\n")
+		assert.Contains(t, buf.String(), "git tag --force --message v1.2:minor v1.2\n")
+	})
+	t.Run("messages take precedence", func(t *testing.T) {
+		buf, _, man := tset()
+		man.MessageTemplate = `{{.Next}}`
+		_, err := man.UpdatePatch(nil, nil, []string{"foo"}, "")
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "git tag --message foo v1.2.4\n")
+	})
+	t.Run("invalid template", func(t *testing.T) {
+		_, _, man := tset()
+		man.MessageTemplate = `{{.Unknown}}`
+		_, err := man.UpdatePatch(nil, nil, nil, "")
+		assert.Error(t, err)
+	})
+}
@@ -4,11 +4,16 @@ import (
 	"encoding/csv"
 	"io"
 	"os"
+	"strings"
 )
 
 type MockRunner struct {
 	echo   io.Writer
 	output io.Reader
+	// outputs are used instead of the output for the matched arguments (joined with spaces).
+	outputs map[string]io.Reader
+	// errs are returned for the matched arguments (joined with spaces).
+	errs map[string]error
 }
 
 func NewMockRunner() Runner {
@@ -22,7 +27,17 @@ func (c *MockRunner) Run(sideEffects bool, stdout io.Writer, args ...string) err
 		return err
 	}
 	w.Flush()
-	if stdout != nil && c.output != nil {
+	if err, ok := c.errs[strings.Join(args, " ")]; ok {
+		return err
+	}
+	if stdout == nil {
+		return nil
+	}
+	if output, ok := c.outputs[strings.Join(args, " ")]; ok {
+		_, err := io.Copy(stdout, output)
+		return err
+	}
+	if c.output != nil {
 		_, err := io.Copy(stdout, c.output)
 		return err
 	}
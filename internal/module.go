@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+var (
+	ErrNoGoMod       = errors.New("go.mod not found")
+	ErrModuleMajor   = errors.New("module path does not match the major version")
+	ErrModuleRewrote = errors.New("module path is rewritten")
+)
+
+// GoModule is a Go module found in the repository.
+type GoModule struct {
+	// Dir is the absolute path of the directory which contains go.mod.
+	Dir string
+	// Path is the module path declared in go.mod.
+	Path string
+}
+
+// FindGoModule searches go.mod from the dir to its parents.
+// The dir must exist, or a typo would find the go.mod of a parent.
+func FindGoModule(dir string) (*GoModule, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		switch {
+		case err == nil:
+			modPath := modfile.ModulePath(data)
+			if modPath == "" {
+				return nil, fmt.Errorf("no module declaration in %s", filepath.Join(dir, "go.mod"))
+			}
+			return &GoModule{Dir: dir, Path: modPath}, nil
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNoGoMod
+		}
+		dir = parent
+	}
+}
+
+// TagPrefix builds the tag prefix of the module in the repository whose root is top.
+// The module in a subdirectory "sub/dir" is tagged like "sub/dir/v1.2.3".
+// A major version subdirectory (e.g. "sub/v2" for "example.com/sub/v2") is not a part of the prefix.
+func (m *GoModule) TagPrefix(top, prefix string) (string, error) {
+	dir, err := filepath.EvalSymlinks(m.Dir)
+	if err != nil {
+		return "", err
+	}
+	top, err = filepath.EvalSymlinks(top)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(top, dir)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("module %s is out of the repository %s", m.Dir, top)
+	}
+	if _, major, ok := module.SplitPathVersion(m.Path); ok && major != "" && path.Base(rel) == strings.TrimPrefix(major, "/") {
+		rel = path.Dir(rel)
+	}
+	if rel == "." {
+		return prefix, nil
+	}
+	return rel + "/" + prefix, nil
+}
+
+// MajorPath builds the module path for the major version (e.g. "example.com/foo/v2" for 2).
+func (m *GoModule) MajorPath(major uint64) string {
+	prefix, _, _ := module.SplitPathVersion(m.Path)
+	switch {
+	case major < 2 && !strings.HasPrefix(prefix, "gopkg.in/"):
+		return prefix
+	case strings.HasPrefix(prefix, "gopkg.in/"):
+		return fmt.Sprintf("%s.v%d", prefix, major)
+	default:
+		return fmt.Sprintf("%s/v%d", prefix, major)
+	}
+}
+
+// CheckMajor verifies that the module path has the suffix for the major version.
+// A module tagged v2.0.0 or later must have the path ending with "/vN" or "go get" cannot resolve it.
+func (m *GoModule) CheckMajor(major uint64) error {
+	_, pathMajor, ok := module.SplitPathVersion(m.Path)
+	if !ok {
+		return fmt.Errorf("invalid module path %q", m.Path)
+	}
+	if err := module.CheckPathMajor(fmt.Sprintf("v%d.0.0", major), pathMajor); err != nil {
+		return fmt.Errorf("%w: module %s should be %s to be tagged v%d", ErrModuleMajor, m.Path, m.MajorPath(major), major)
+	}
+	return nil
+}
+
+// RewriteMajor rewrites the module path in go.mod and imports of the module in the module tree for the major version.
+func (m *GoModule) RewriteMajor(major uint64) error {
+	newPath := m.MajorPath(major)
+	if newPath == m.Path {
+		return nil
+	}
+	modName := filepath.Join(m.Dir, "go.mod")
+	data, err := os.ReadFile(modName)
+	if err != nil {
+		return err
+	}
+	file, err := modfile.Parse(modName, data, nil)
+	if err != nil {
+		return err
+	}
+	if err := file.AddModuleStmt(newPath); err != nil {
+		return err
+	}
+	if err := writeFileKeepMode(modName, modfile.Format(file.Syntax)); err != nil {
+		return err
+	}
+
+	if err := filepath.WalkDir(m.Dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name == m.Dir {
+				return nil
+			}
+			if base := d.Name(); base == "vendor" || base == "testdata" || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(name, "go.mod")); err == nil {
+				// nested module
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") {
+			return nil
+		}
+		return rewriteImports(name, m.Path, newPath)
+	}); err != nil {
+		return err
+	}
+	m.Path = newPath
+	return nil
+}
+
+func rewriteImports(name, oldPath, newPath string) error {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.ImportsOnly)
+	if err != nil {
+		return err
+	}
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return err
+		}
+		if p != oldPath && !strings.HasPrefix(p, oldPath+"/") {
+			continue
+		}
+		edits = append(edits, edit{
+			start: fset.Position(imp.Path.Pos()).Offset,
+			end:   fset.Position(imp.Path.End()).Offset,
+			text:  strconv.Quote(newPath + strings.TrimPrefix(p, oldPath)),
+		})
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		src = append(src[:e.start:e.start], append([]byte(e.text), src[e.end:]...)...)
+	}
+	return writeFileKeepMode(name, src)
+}
+
+func writeFileKeepMode(name string, data []byte) error {
+	stat, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, stat.Mode().Perm())
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoModule(t *testing.T) {
+	top := t.TempDir()
+	write := func(t *testing.T, rel, content string) {
+		t.Helper()
+		name := filepath.Join(top, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0644))
+	}
+	write(t, "go.mod", "module example.com/repo\n")
+	write(t, "sub/dir/go.mod", "module example.com/repo/sub/dir\n")
+	write(t, "sub/dir/pkg/foo.go", "package pkg\n")
+	write(t, "major/v2/go.mod", "module example.com/repo/major/v2\n")
+
+	t.Run("find", func(t *testing.T) {
+		mod, err := FindGoModule(filepath.Join(top, "sub", "dir", "pkg"))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(top, "sub", "dir"), mod.Dir)
+		assert.Equal(t, "example.com/repo/sub/dir", mod.Path)
+	})
+	t.Run("not found", func(t *testing.T) {
+		_, err := FindGoModule(t.TempDir())
+		assert.ErrorIs(t, err, ErrNoGoMod)
+	})
+	t.Run("missing dir", func(t *testing.T) {
+		_, err := FindGoModule(filepath.Join(top, "sub", "typo"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		_, err = FindGoModule(filepath.Join(top, "sub", "dir", "pkg", "foo.go"))
+		assert.Error(t, err)
+	})
+	t.Run("prefix", func(t *testing.T) {
+		for dir, want := range map[string]string{
+			".":        "v",
+			"sub/dir":  "sub/dir/v",
+			"major/v2": "major/v",
+		} {
+			mod, err := FindGoModule(filepath.Join(top, dir))
+			require.NoError(t, err)
+			prefix, err := mod.TagPrefix(top, "v")
+			assert.NoError(t, err)
+			assert.Equal(t, want, prefix, dir)
+		}
+	})
+	t.Run("out of repository", func(t *testing.T) {
+		mod, err := FindGoModule(filepath.Join(top, "sub", "dir"))
+		require.NoError(t, err)
+		_, err = mod.TagPrefix(filepath.Join(top, "major"), "v")
+		assert.Error(t, err)
+	})
+	t.Run("major path", func(t *testing.T) {
+		assert.Equal(t, "example.com/foo", (&GoModule{Path: "example.com/foo"}).MajorPath(1))
+		assert.Equal(t, "example.com/foo/v2", (&GoModule{Path: "example.com/foo"}).MajorPath(2))
+		assert.Equal(t, "example.com/foo/v3", (&GoModule{Path: "example.com/foo/v2"}).MajorPath(3))
+		assert.Equal(t, "example.com/foo", (&GoModule{Path: "example.com/foo/v2"}).MajorPath(1))
+		assert.Equal(t, "gopkg.in/foo.v3", (&GoModule{Path: "gopkg.in/foo.v2"}).MajorPath(3))
+	})
+	t.Run("check major", func(t *testing.T) {
+		assert.NoError(t, (&GoModule{Path: "example.com/foo"}).CheckMajor(0))
+		assert.NoError(t, (&GoModule{Path: "example.com/foo"}).CheckMajor(1))
+		assert.ErrorIs(t, (&GoModule{Path: "example.com/foo"}).CheckMajor(2), ErrModuleMajor)
+		assert.NoError(t, (&GoModule{Path: "example.com/foo/v2"}).CheckMajor(2))
+		assert.ErrorIs(t, (&GoModule{Path: "example.com/foo/v2"}).CheckMajor(3), ErrModuleMajor)
+		assert.NoError(t, (&GoModule{Path: "gopkg.in/foo.v2"}).CheckMajor(2))
+	})
+	t.Run("rewrite major", func(t *testing.T) {
+		top := t.TempDir()
+		write := func(rel, content string) {
+			name := filepath.Join(top, rel)
+			require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+			require.NoError(t, os.WriteFile(name, []byte(content), 0644))
+		}
+		read := func(rel string) string {
+			data, err := os.ReadFile(filepath.Join(top, rel))
+			require.NoError(t, err)
+			return string(data)
+		}
+		write("go.mod", "module example.com/foo\n\ngo 1.17\n")
+		write("main.go", "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/foo/pkg\"\n\t\"example.com/foobar\"\n)\n")
+		write("pkg/pkg.go", "package pkg\n\nimport _ \"example.com/foo\"\n")
+		write("vendor/example.com/foo/pkg/pkg.go", "package pkg\n\nimport _ \"example.com/foo/pkg\"\n")
+		write("nested/go.mod", "module example.com/nested\n")
+		write("nested/nested.go", "package nested\n\nimport _ \"example.com/foo/pkg\"\n")
+
+		mod := &GoModule{Dir: top, Path: "example.com/foo"}
+		require.NoError(t, mod.RewriteMajor(2))
+		assert.Equal(t, "example.com/foo/v2", mod.Path)
+		assert.Equal(t, "module example.com/foo/v2\n\ngo 1.17\n", read("go.mod"))
+		assert.Equal(t, "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/foo/v2/pkg\"\n\t\"example.com/foobar\"\n)\n", read("main.go"))
+		assert.Equal(t, "package pkg\n\nimport _ \"example.com/foo/v2\"\n", read("pkg/pkg.go"))
+		assert.Equal(t, "package pkg\n\nimport _ \"example.com/foo/pkg\"\n", read("vendor/example.com/foo/pkg/pkg.go"))
+		assert.Equal(t, "package nested\n\nimport _ \"example.com/foo/pkg\"\n", read("nested/nested.go"))
+	})
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Placeholders in tag patterns.
+const (
+	PatternVersion = "{{version}}"
+	PatternModule  = "{{module}}"
+)
+
+// ParseTagPattern splits the tag pattern (e.g. "release-{{version}}" or "{{module}}/v{{version}}")
+// into the prefix and the suffix of the version. "{{module}}" is replaced with the module directory.
+func ParseTagPattern(pattern, module string) (prefix, suffix string, err error) {
+	if strings.Count(pattern, PatternVersion) != 1 {
+		return "", "", fmt.Errorf("invalid tag pattern %q: it should have one %s", pattern, PatternVersion)
+	}
+	if strings.Contains(pattern, PatternModule) {
+		if module == "" {
+			return "", "", fmt.Errorf("invalid tag pattern %q: %s needs a module in a subdirectory (--module)", pattern, PatternModule)
+		}
+		pattern = strings.ReplaceAll(pattern, PatternModule, module)
+	}
+	prefix, suffix, _ = strings.Cut(pattern, PatternVersion)
+	if strings.Contains(prefix+suffix, "{{") {
+		return "", "", fmt.Errorf("invalid tag pattern %q: unknown placeholder", pattern)
+	}
+	return prefix, suffix, nil
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTagPattern(t *testing.T) {
+	for _, c := range []struct {
+		pattern, module string
+		prefix, suffix  string
+	}{
+		{pattern: "v{{version}}", prefix: "v"},
+		{pattern: "release-{{version}}", prefix: "release-"},
+		{pattern: "v{{version}}-final", prefix: "v", suffix: "-final"},
+		{pattern: "{{module}}/v{{version}}", module: "sub/mod", prefix: "sub/mod/v"},
+	} {
+		prefix, suffix, err := ParseTagPattern(c.pattern, c.module)
+		require.NoError(t, err, c.pattern)
+		assert.Equal(t, c.prefix, prefix, c.pattern)
+		assert.Equal(t, c.suffix, suffix, c.pattern)
+	}
+
+	for _, pattern := range []string{"v", "{{version}}-{{version}}", "{{module}}/v{{version}}", "{{name}}-{{version}}"} {
+		_, _, err := ParseTagPattern(pattern, "")
+		assert.Error(t, err, pattern)
+	}
+}
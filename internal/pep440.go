@@ -0,0 +1,308 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// PEP440 is the version scheme of Python packages (e.g. "1.2.0", "1.2.0rc1", "1.2.0.post1" or "1.2.0.dev3").
+// Tags in other spellings of the PEP 440 (e.g. "1.2.0-rc.1" or "1.2.0alpha1") are read too,
+// and the next versions are made in the normalized form.
+// SPEC: https://peps.python.org/pep-0440/
+type PEP440 struct{}
+
+var pep440Pattern = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d+)?)?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d+)?)?` +
+	`(?:[-_.]?(dev)[-_.]?(\d+)?)?$`)
+
+// pep440Phases are the phases of pre-releases in order.
+var pep440Phases = []string{"a", "b", "rc"}
+
+// pep440Spellings normalizes the phases of pre-releases.
+var pep440Spellings = map[string]string{
+	"a":       "a",
+	"alpha":   "a",
+	"b":       "b",
+	"beta":    "b",
+	"rc":      "rc",
+	"c":       "rc",
+	"pre":     "rc",
+	"preview": "rc",
+}
+
+func (PEP440) Parse(s string) (Ver, bool) {
+	m := pep440Pattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	v := pep440Ver{text: s}
+	for _, f := range strings.Split(m[1], ".") {
+		n, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		v.nums = append(v.nums, n)
+	}
+	// the omitted number is zero (e.g. "1.2.0rc" is "1.2.0rc0")
+	num := func(s string) (uint64, bool) {
+		if s == "" {
+			return 0, true
+		}
+		n, err := strconv.ParseUint(s, 10, 64)
+		return n, err == nil
+	}
+	if m[2] != "" {
+		n, ok := num(m[3])
+		if !ok {
+			return nil, false
+		}
+		v.pre = &pep440Pre{phase: pep440Spellings[strings.ToLower(m[2])], num: n}
+	}
+	if m[4] != "" || m[5] != "" {
+		n, ok := num(m[4] + m[6])
+		if !ok {
+			return nil, false
+		}
+		v.post = &n
+	}
+	if m[7] != "" {
+		n, ok := num(m[8])
+		if !ok {
+			return nil, false
+		}
+		v.dev = &n
+	}
+	return v, true
+}
+
+func (PEP440) Zero() Ver {
+	return pep440Ver{nums: []uint64{0, 0, 0}}
+}
+
+// Bump changes the version like the SemVer, with the phase and the number of the pre-release notation
+// (e.g. "rc" or "rc.2"; "rc" is "rc1"). In addition, "post" increments the post-release,
+// and "dev" increments the development release or starts it for the next version.
+func (s PEP440) Bump(cur Ver, c Change) (Ver, error) {
+	v, ok := cur.(pep440Ver)
+	if !ok {
+		v = s.Zero().(pep440Ver)
+	}
+	if len(c.Build) > 0 {
+		return nil, fmt.Errorf("%w: PEP 440 has no build notation", ErrUnsupported)
+	}
+	pre, err := parsePEP440Pre(c.Pre)
+	if err != nil {
+		return nil, err
+	}
+	next := pep440Ver{nums: v.nums}
+	switch c.Kind {
+	case "major", "minor", "patch":
+		next.nums = bumpRelease(v.nums, releaseLevel(c.Kind))
+		next.pre = pre
+	case "pre":
+		switch {
+		case pre != nil:
+			next.pre = pre
+		case v.pre == nil || v.post != nil:
+			return nil, fmt.Errorf("%s is not a pre-release", v)
+		case v.dev != nil:
+			// the pre-release which the development release is for
+			next.pre = v.pre
+		default:
+			next.pre = v.pre.next()
+		}
+	case "release":
+		if v.pre == nil && v.dev == nil {
+			return nil, fmt.Errorf("%s is not a pre-release", v)
+		}
+		next.post = v.post
+	case "post":
+		switch {
+		case v.pre != nil || (v.dev != nil && v.post == nil):
+			return nil, fmt.Errorf("%s is a pre-release: release it before the post-release", v)
+		case v.dev != nil:
+			// the post-release which the development release is for
+			next.post = v.post
+		default:
+			next.post = increment(v.post)
+		}
+	case "dev":
+		next.pre, next.post = v.pre, v.post
+		switch {
+		case v.dev != nil:
+		case v.post != nil:
+			next.post = increment(v.post)
+		case v.pre != nil:
+			next.pre = v.pre.next()
+		default:
+			next.nums = bumpRelease(v.nums, releaseLevel("patch"))
+		}
+		next.dev = increment(v.dev)
+	default:
+		return nil, fmt.Errorf("%w: %s of PEP 440", ErrUnsupported, c.Kind)
+	}
+	if next.Compare(v) <= 0 {
+		return nil, fmt.Errorf("%s rewinds version order from %s", next, v)
+	}
+	return next, nil
+}
+
+// increment increments the number, or starts it from 1.
+func increment(n *uint64) *uint64 {
+	next := uint64(1)
+	if n != nil {
+		next = *n + 1
+	}
+	return &next
+}
+
+var pep440PreID = regexp.MustCompile(`^([a-z]+)(\d+)$`)
+
+// parsePEP440Pre reads the pre-release notation in the SemVer form (e.g. "rc.2", "rc2" or "alpha").
+func parsePEP440Pre(ids []semver.PRVersion) (*pep440Pre, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	word, num := ids[0].VersionStr, ids[1:]
+	if m := pep440PreID.FindStringSubmatch(word); m != nil && len(num) == 0 {
+		// "rc2" is "rc.2"
+		word = m[1]
+		n, err := semver.NewPRVersion(m[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pre-release number %q for PEP 440", m[2])
+		}
+		num = []semver.PRVersion{n}
+	}
+	phase, ok := pep440Spellings[word]
+	if !ok || len(num) > 1 {
+		return nil, fmt.Errorf("invalid pre-release %q for PEP 440: it should be a, b or rc with a number", formatPre(ids))
+	}
+	pre := &pep440Pre{phase: phase, num: 1}
+	if len(num) == 1 {
+		if !num[0].IsNum {
+			return nil, fmt.Errorf("invalid pre-release number %q for PEP 440", num[0])
+		}
+		pre.num = num[0].VersionNum
+	}
+	return pre, nil
+}
+
+// pep440Pre is a pre-release notation (e.g. "rc1").
+type pep440Pre struct {
+	phase string
+	num   uint64
+}
+
+func (p *pep440Pre) next() *pep440Pre {
+	return &pep440Pre{phase: p.phase, num: p.num + 1}
+}
+
+func (p *pep440Pre) compare(o *pep440Pre) int {
+	if p.phase != o.phase {
+		for _, phase := range pep440Phases {
+			switch phase {
+			case p.phase:
+				return -1
+			case o.phase:
+				return 1
+			}
+		}
+	}
+	return compareRelease([]uint64{p.num}, []uint64{o.num})
+}
+
+// pep440Ver is a version of the PEP440.
+type pep440Ver struct {
+	nums []uint64
+	pre  *pep440Pre
+	post *uint64
+	dev  *uint64
+	// text is the version as it is in the tag, which may not be normalized.
+	text string
+}
+
+func (v pep440Ver) String() string {
+	if v.text != "" {
+		return v.text
+	}
+	s := formatRelease(v.nums)
+	if v.pre != nil {
+		s += v.pre.phase + strconv.FormatUint(v.pre.num, 10)
+	}
+	if v.post != nil {
+		s += ".post" + strconv.FormatUint(*v.post, 10)
+	}
+	if v.dev != nil {
+		s += ".dev" + strconv.FormatUint(*v.dev, 10)
+	}
+	return s
+}
+
+// Compare orders versions by the release, the pre-release, the post-release and the development release.
+// The development release of a release (e.g. "1.2.0.dev1") is lower than its pre-releases.
+func (v pep440Ver) Compare(o Ver) int {
+	w, ok := o.(pep440Ver)
+	if !ok {
+		return v.Semver().Compare(o.Semver())
+	}
+	if c := compareRelease(v.nums, w.nums); c != 0 {
+		return c
+	}
+	if c := compareRelease([]uint64{v.preRank()}, []uint64{w.preRank()}); c != 0 {
+		return c
+	}
+	if v.pre != nil {
+		if c := v.pre.compare(w.pre); c != 0 {
+			return c
+		}
+	}
+	if c := compareOptional(v.post, w.post, false); c != 0 {
+		return c
+	}
+	return compareOptional(v.dev, w.dev, true)
+}
+
+// preRank ranks the development release of a release, pre-releases, and the others.
+func (v pep440Ver) preRank() uint64 {
+	switch {
+	case v.pre != nil:
+		return 1
+	case v.dev != nil && v.post == nil:
+		return 0
+	}
+	return 2
+}
+
+// compareOptional compares the numbers. The missing number is the highest if missingHigh, or the lowest.
+func compareOptional(a, b *uint64, missingHigh bool) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil && missingHigh, b == nil && !missingHigh:
+		return 1
+	case a == nil, b == nil:
+		return -1
+	}
+	return compareRelease([]uint64{*a}, []uint64{*b})
+}
+
+// Semver maps the pre-release and the development release to the pre-release notation of the SemVer
+// (e.g. "1.2.0rc1.dev2" to "1.2.0-rc.1.dev.2"), and the post-release to the build notation (e.g. "1.2.0+post.1").
+func (v pep440Ver) Semver() semver.Version {
+	s := releaseSemver(v.nums)
+	if v.pre != nil {
+		s.Pre = append(s.Pre, semver.PRVersion{VersionStr: v.pre.phase}, semver.PRVersion{VersionNum: v.pre.num, IsNum: true})
+	}
+	if v.dev != nil {
+		s.Pre = append(s.Pre, semver.PRVersion{VersionStr: "dev"}, semver.PRVersion{VersionNum: *v.dev, IsNum: true})
+	}
+	if v.post != nil {
+		s.Build = []string{"post", strconv.FormatUint(*v.post, 10)}
+	}
+	return s
+}
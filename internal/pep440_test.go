@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPEP440(t *testing.T) {
+	scheme := PEP440{}
+	t.Run("parse", func(t *testing.T) {
+		for _, s := range []string{"1", "1.2.0", "1.2.0a1", "1.2.0b2", "1.2.0rc10", "1.2.0.post1", "1.2.0.dev3", "1.2.0rc1.post2.dev3"} {
+			v, ok := scheme.Parse(s)
+			require.True(t, ok, s)
+			assert.Equal(t, s, v.String())
+		}
+		for _, s := range []string{"", "1.2.0-foo", "1!1.2.0", "1.2.0+local", "1.2.0rc1rc2", "1.2.0.dev1.post1"} {
+			_, ok := scheme.Parse(s)
+			assert.False(t, ok, s)
+		}
+		for s, normalized := range map[string]string{
+			"1.02":           "1.2",
+			"1.2.0-rc.1":     "1.2.0rc1",
+			"1.2.0alpha1":    "1.2.0a1",
+			"1.2.0c":         "1.2.0rc0",
+			"1.2.0-1":        "1.2.0.post1",
+			"1.2.0_post_2":   "1.2.0.post2",
+			"1.2.0-RC1-dev2": "1.2.0rc1.dev2",
+		} {
+			v, ok := scheme.Parse(s)
+			require.True(t, ok, s)
+			assert.Equal(t, s, v.String(), "it keeps the tag as it is")
+			n, _ := scheme.Parse(normalized)
+			assert.Equal(t, 0, v.Compare(n), s)
+		}
+		v, _ := scheme.Parse("1.2.0rc1.dev2")
+		assert.Equal(t, semver.MustParse("1.2.0-rc.1.dev.2"), v.Semver())
+		v, _ = scheme.Parse("1.2.0.post1")
+		assert.Equal(t, semver.MustParse("1.2.0+post.1"), v.Semver())
+	})
+	t.Run("compare", func(t *testing.T) {
+		assert.Equal(t,
+			[]string{"1.1", "1.2.0.dev1", "1.2.0a1.dev1", "1.2.0a1", "1.2.0a2", "1.2.0b1", "1.2.0rc1",
+				"1.2.0", "1.2.0.post1.dev1", "1.2.0.post1", "1.2.1", "1.10.0"},
+			sortTags(t, scheme, "1.2.0", "1.2.0rc1", "1.2.0.post1", "1.10.0", "1.2.0a2", "1.2.0.dev1",
+				"1.2.1", "1.2.0b1", "1.2.0.post1.dev1", "1.1", "1.2.0a1", "1.2.0a1.dev1"))
+	})
+	t.Run("bump", func(t *testing.T) {
+		rc := []semver.PRVersion{{VersionStr: "rc"}}
+		for _, tc := range []struct {
+			cur    string
+			change Change
+			next   string
+		}{
+			{"", Change{Kind: "patch"}, "0.0.1"},
+			{"1.2.3", Change{Kind: "minor", Pre: []semver.PRVersion{{VersionStr: "a"}}}, "1.3.0a1"},
+			{"1.3.0a1", Change{Kind: "pre"}, "1.3.0a2"},
+			{"1.3.0a2", Change{Kind: "pre", Pre: []semver.PRVersion{{VersionStr: "beta"}, {VersionNum: 3, IsNum: true}}}, "1.3.0b3"},
+			{"1.3.0b3", Change{Kind: "pre", Pre: rc}, "1.3.0rc1"},
+			{"1.3.0rc1", Change{Kind: "pre", Pre: []semver.PRVersion{{VersionStr: "rc3"}}}, "1.3.0rc3"},
+			{"1.3.0-rc.3", Change{Kind: "release"}, "1.3.0"},
+			{"1.3", Change{Kind: "patch"}, "1.3.1"},
+			{"1.3.0", Change{Kind: "post"}, "1.3.0.post1"},
+			{"1.3.0.post1", Change{Kind: "post"}, "1.3.0.post2"},
+			{"1.3.0", Change{Kind: "dev"}, "1.3.1.dev1"},
+			{"1.3.1.dev1", Change{Kind: "dev"}, "1.3.1.dev2"},
+			{"1.3.1.dev2", Change{Kind: "pre", Pre: rc}, "1.3.1rc1"},
+			{"1.3.1.dev2", Change{Kind: "release"}, "1.3.1"},
+			{"1.3.1rc1", Change{Kind: "dev"}, "1.3.1rc2.dev1"},
+			{"1.3.1rc2.dev1", Change{Kind: "pre"}, "1.3.1rc2"},
+			{"1.3.0.post2", Change{Kind: "dev"}, "1.3.0.post3.dev1"},
+			{"1.3.0.post3.dev1", Change{Kind: "post"}, "1.3.0.post3"},
+		} {
+			next, err := bump(t, scheme, tc.cur, tc.change)
+			require.NoError(t, err, tc)
+			assert.Equal(t, tc.next, next, tc)
+		}
+
+		_, err := bump(t, scheme, "1.3.0rc2", Change{Kind: "pre", Pre: rc})
+		assert.ErrorContains(t, err, "rewinds")
+		_, err = bump(t, scheme, "1.3.0", Change{Kind: "pre", Pre: rc})
+		assert.ErrorContains(t, err, "rewinds")
+		_, err = bump(t, scheme, "1.3.0", Change{Kind: "pre"})
+		assert.ErrorContains(t, err, "not a pre-release")
+		_, err = bump(t, scheme, "1.3.0rc1", Change{Kind: "post"})
+		assert.ErrorContains(t, err, "release it before the post-release")
+		_, err = bump(t, scheme, "1.3.0", Change{Kind: "patch", Pre: []semver.PRVersion{{VersionStr: "final"}}})
+		assert.ErrorContains(t, err, "invalid pre-release")
+		_, err = bump(t, scheme, "1.3.0", Change{Kind: "build", Build: []string{"5"}})
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+}
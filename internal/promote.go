@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// DefaultChannels are the pre-release channels in order, without the configuration.
+var DefaultChannels = []string{"alpha", "beta", "rc"}
+
+// channels are the pre-release channels in order. The PEP 440 has its own phases by default.
+func (m *Manager) channels() []string {
+	if len(m.Channels) > 0 {
+		return m.Channels
+	}
+	if _, ok := m.scheme().(PEP440); ok {
+		return pep440Phases
+	}
+	return DefaultChannels
+}
+
+// channelOf finds the channel of the pre-release notation by its first identifier: -1 for others.
+func (m *Manager) channelOf(pre []semver.PRVersion) int {
+	if len(pre) == 0 || pre[0].IsNum {
+		return -1
+	}
+	for i, c := range m.channels() {
+		if c == pre[0].VersionStr {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkChannel rejects the pre-release notation in a channel before the channel of the current version.
+func (m *Manager) checkChannel(cur Ver, pre []semver.PRVersion) error {
+	i, j := m.channelOf(cur.Semver().Pre), m.channelOf(pre)
+	if i < 0 || j < 0 || j >= i {
+		return nil
+	}
+	channels := m.channels()
+	return fmt.Errorf("%s is before %s in the channels %s: it rewinds version order from %s", channels[j], channels[i], strings.Join(channels, ", "), cur)
+}
+
+// Promote creates a tag for the first pre-release in the next channel (e.g. "1.3.0-beta.1" for "1.3.0-alpha.4").
+func (m *Manager) Promote(build, msg []string, file string) (*Result, error) {
+	return m.retry(func() (*Result, error) {
+		cur, err := m.getVer()
+		if err != nil {
+			return nil, err
+		}
+		channels := m.channels()
+		pre := cur.Semver().Pre
+		if len(pre) == 0 {
+			return nil, fmt.Errorf("%s is not a pre-release", cur)
+		}
+		i := m.channelOf(pre)
+		switch {
+		case i < 0:
+			return nil, fmt.Errorf("%s is not in the channels %s", cur, strings.Join(channels, ", "))
+		case i == len(channels)-1:
+			return nil, fmt.Errorf("%s is in the last channel %s: release it instead", cur, channels[i])
+		}
+		next := []semver.PRVersion{{VersionStr: channels[i+1]}, {VersionNum: 1, IsNum: true}}
+		return m.updateVer(cur, "pre", next, build, msg, file)
+	})
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/blang/semver/v4"
+)
+
+// Result is the outcome of a command.
+type Result struct {
+	// Tag is the tag which is got or validated.
+	Tag string `json:"tag,omitempty"`
+	// Bump is the bump level decided from the commits (e.g. "minor") without creating a tag.
+	Bump string `json:"bump,omitempty"`
+	// Previous is the version tag before the update or the deletion.
+	Previous string `json:"previous,omitempty"`
+	// Next is the version tag after the update or the deletion.
+	Next    string       `json:"next,omitempty"`
+	Version *VersionInfo `json:"version,omitempty"`
+	// Commit is the commit which the tag points at.
+	Commit  string   `json:"commit,omitempty"`
+	Remote  string   `json:"remote,omitempty"`
+	Created []string `json:"created,omitempty"`
+	Deleted []string `json:"deleted,omitempty"`
+	// Moved are the floating tags (e.g. "v1") which pointed at another commit.
+	Moved []string `json:"moved,omitempty"`
+	// Retries is the number of times tagging is retried since another run took the version.
+	Retries int `json:"retries,omitempty"`
+	// Warnings are the failures after the tags are pushed (e.g. of the post-tag hook), which do not undo them.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type VersionInfo struct {
+	Major uint64   `json:"major"`
+	Minor uint64   `json:"minor"`
+	Patch uint64   `json:"patch"`
+	Pre   []string `json:"pre,omitempty"`
+	Build []string `json:"build,omitempty"`
+}
+
+func NewVersionInfo(v semver.Version) *VersionInfo {
+	info := &VersionInfo{
+		Major: v.Major,
+		Minor: v.Minor,
+		Patch: v.Patch,
+		Build: v.Build,
+	}
+	for _, p := range v.Pre {
+		info.Pre = append(info.Pre, p.String())
+	}
+	return info
+}
+
+func (r *Result) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
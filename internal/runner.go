@@ -1,9 +1,16 @@
 package internal
 
 import (
+	"context"
 	"io"
 )
 
 type Runner interface {
 	Run(sideEffects bool, stdout io.Writer, args ...string) error
 }
+
+// ContextRunner is a Runner which can stop the command with the context (e.g. for a timeout).
+type ContextRunner interface {
+	Runner
+	RunContext(ctx context.Context, sideEffects bool, stdout io.Writer, args ...string) error
+}
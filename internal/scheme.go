@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blang/semver/v4"
+)
+
+// Scheme parses and bumps versions in version tags. The versions are compared and formatted by themselves (see Ver).
+type Scheme interface {
+	// Parse parses the version in a tag name without the prefix and the suffix.
+	Parse(s string) (Ver, bool)
+	// Zero is the version before the first one.
+	Zero() Ver
+	// Bump computes the next version of cur with the change.
+	Bump(cur Ver, c Change) (Ver, error)
+}
+
+// Change is what a command changes in the version.
+type Change struct {
+	// Kind is "major", "minor", "patch", "pre", "release", "build", "post" or "dev".
+	Kind string
+	// Pre is the pre-release notation to put. Empty increments the current one for "pre".
+	Pre   []semver.PRVersion
+	Build []string
+	// Date is when the version is made.
+	Date time.Time
+}
+
+// Schemes are the built-in schemes by the names. CalVer is made with ParseCalVer.
+var Schemes = map[string]Scheme{
+	"semver":          StrictSemVer{},
+	"semver-tolerant": TolerantSemVer{},
+	"pep440":          PEP440{},
+}
+
+// StrictSemVer is the Semantic Versioning 2.0.0 (e.g. "1.2.3-rc.1+build.5").
+// SPEC: https://semver.org/
+type StrictSemVer struct{}
+
+func (StrictSemVer) Parse(s string) (Ver, bool) {
+	v, err := semver.Parse(s)
+	if err != nil {
+		return nil, false
+	}
+	return SemVer(v), true
+}
+
+func (StrictSemVer) Zero() Ver {
+	return SemVer{}
+}
+
+func (StrictSemVer) Bump(cur Ver, c Change) (Ver, error) {
+	v, err := bumpSemver(cur.Semver(), c)
+	if err != nil {
+		return nil, err
+	}
+	return SemVer(v), nil
+}
+
+// bumpSemver updates the SemVer with the Updater.
+func bumpSemver(v semver.Version, c Change) (semver.Version, error) {
+	u := NewUpdater(v)
+	var upd UpdatePre
+	switch c.Kind {
+	case "major":
+		upd = u.Major()
+	case "minor":
+		upd = u.Minor()
+	case "patch":
+		upd = u.Patch()
+	case "pre":
+		upd = u
+	case "release":
+		return u.Release().Build(c.Build...).Version()
+	case "build":
+		return u.Build(c.Build...).Version()
+	default:
+		return semver.Version{}, fmt.Errorf("%w: %s", ErrUnsupported, c.Kind)
+	}
+	return upd.Pre(c.Pre...).Build(c.Build...).Version()
+}
+
+// releaseLevel is the index of the release number which the kind increments: -1 for other kinds.
+func releaseLevel(kind string) int {
+	switch kind {
+	case "major":
+		return 0
+	case "minor":
+		return 1
+	case "patch":
+		return 2
+	}
+	return -1
+}
+
+// bumpRelease increments the release number at the level, and resets the following ones.
+func bumpRelease(nums []uint64, level int) []uint64 {
+	next := append([]uint64{}, nums...)
+	for len(next) <= level {
+		next = append(next, 0)
+	}
+	next[level]++
+	for i := level + 1; i < len(next); i++ {
+		next[i] = 0
+	}
+	return next
+}
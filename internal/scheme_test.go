@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bump parses the version in the scheme and bumps it with the change.
+func bump(t *testing.T, scheme Scheme, cur string, c Change) (string, error) {
+	t.Helper()
+	v := scheme.Zero()
+	if cur != "" {
+		var ok bool
+		v, ok = scheme.Parse(cur)
+		require.True(t, ok, cur)
+	}
+	next, err := scheme.Bump(v, c)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
+
+// sortTags parses the versions in the scheme and sorts them.
+func sortTags(t *testing.T, scheme Scheme, tags ...string) []string {
+	t.Helper()
+	var vers Vers
+	for _, tag := range tags {
+		v, ok := scheme.Parse(tag)
+		require.True(t, ok, tag)
+		vers = append(vers, v)
+	}
+	sort.Sort(vers)
+	sorted := make([]string, len(vers))
+	for i, v := range vers {
+		sorted[i] = v.String()
+	}
+	return sorted
+}
+
+func TestStrictSemVer(t *testing.T) {
+	scheme := StrictSemVer{}
+	for _, s := range []string{"1.2", "1.2.3.4", "01.2.3"} {
+		_, ok := scheme.Parse(s)
+		assert.False(t, ok, s)
+	}
+	for _, tc := range []struct {
+		cur    string
+		change Change
+		next   string
+	}{
+		{"", Change{Kind: "patch"}, "0.0.1"},
+		{"1.2.3", Change{Kind: "minor", Pre: []semver.PRVersion{{VersionStr: "rc"}}}, "1.3.0-rc"},
+		{"1.3.0-rc", Change{Kind: "pre"}, "1.3.0-rc.2"},
+		{"1.3.0-rc.2", Change{Kind: "release", Build: []string{"5"}}, "1.3.0+5"},
+		{"1.3.0", Change{Kind: "build", Build: []string{"6"}}, "1.3.0+6"},
+	} {
+		next, err := bump(t, scheme, tc.cur, tc.change)
+		require.NoError(t, err, tc)
+		assert.Equal(t, tc.next, next, tc)
+	}
+	_, err := bump(t, scheme, "1.2.3", Change{Kind: "post"})
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestTolerantSemVer(t *testing.T) {
+	scheme := TolerantSemVer{}
+	t.Run("parse", func(t *testing.T) {
+		for _, s := range []string{"1", "1.2", "1.02", "1.2.3.4", "1.2-rc.1+5"} {
+			v, ok := scheme.Parse(s)
+			require.True(t, ok, s)
+			assert.Equal(t, s, v.String(), "it keeps the tag as it is")
+		}
+		for _, s := range []string{"", "1.", "1.2-", "1.2-rc.01", "x1.2"} {
+			_, ok := scheme.Parse(s)
+			assert.False(t, ok, s)
+		}
+		v, _ := scheme.Parse("1.2.3.4-rc.1")
+		assert.Equal(t, semver.MustParse("1.2.3-rc.1"), v.Semver())
+	})
+	t.Run("compare", func(t *testing.T) {
+		assert.Equal(t,
+			[]string{"1", "1.1-rc", "1.1", "1.1.0.1", "1.02.0", "1.10"},
+			sortTags(t, scheme, "1.10", "1.1.0.1", "1.1", "1.02.0", "1", "1.1-rc"))
+	})
+	t.Run("bump", func(t *testing.T) {
+		for _, tc := range []struct {
+			cur    string
+			change Change
+			next   string
+		}{
+			{"", Change{Kind: "minor"}, "0.1.0"},
+			{"1.2", Change{Kind: "minor"}, "1.3"},
+			{"1.2", Change{Kind: "patch"}, "1.2.1"},
+			{"1.2.3.4", Change{Kind: "patch"}, "1.2.4.0"},
+			{"1.02", Change{Kind: "major", Pre: []semver.PRVersion{{VersionStr: "rc"}}}, "2.0-rc"},
+			{"2.0-rc", Change{Kind: "pre"}, "2.0-rc.2"},
+			{"2.0-rc.2", Change{Kind: "release"}, "2.0"},
+		} {
+			next, err := bump(t, scheme, tc.cur, tc.change)
+			require.NoError(t, err, tc)
+			assert.Equal(t, tc.next, next, tc)
+		}
+		_, err := bump(t, scheme, "1.2", Change{Kind: "pre", Pre: []semver.PRVersion{{VersionStr: "rc"}}})
+		assert.Error(t, err, "it rewinds the version")
+	})
+}
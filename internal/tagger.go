@@ -3,13 +3,33 @@ package internal
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"time"
 )
 
 type Tagger struct {
 	Runner  Runner
 	Workdir string
 	PushTo  string
+	// Merged limits tags to ones reachable from the revision.
+	Merged string
+	// Sign makes tags signed with the default key (GPG or SSH by gpg.format).
+	Sign bool
+	// LocalUser makes tags signed with the key.
+	LocalUser string
+	// RemoteOnly is the remote to read tags from by ls-remote instead of the local tags.
+	// Nothing in the local repository is changed to read them.
+	RemoteOnly string
+	// FetchFrom are the remotes to fetch tags from. If it is empty, the default remote is used.
+	FetchFrom []string
+	// PruneTags deletes local tags which do not exist in the remote when fetching.
+	PruneTags bool
+	// FetchTimeout stops fetching tags after the duration. Zero means no timeout.
+	FetchTimeout time.Duration
 }
 
 func (t *Tagger) run(sideEffects bool, w io.Writer, args ...string) error {
@@ -20,25 +40,173 @@ func (t *Tagger) run(sideEffects bool, w io.Writer, args ...string) error {
 	}
 }
 
-func (t *Tagger) CreateTag(tag string, message []string, file string) error {
-	args := []string{"tag"}
+// runContext runs the command with the context if the Runner supports it.
+func (t *Tagger) runContext(ctx context.Context, sideEffects bool, w io.Writer, args ...string) error {
+	runner, ok := t.Runner.(ContextRunner)
+	if !ok {
+		return t.run(sideEffects, w, args...)
+	}
+	if t.Workdir != "" {
+		args = append([]string{"-C", t.Workdir}, args...)
+	}
+	return runner.RunContext(ctx, sideEffects, w, args...)
+}
+
+func (t *Tagger) signs() bool {
+	return t.Sign || t.LocalUser != ""
+}
+
+// tagArgs builds the arguments to create the tag with options for the message and the signature.
+func (t *Tagger) tagArgs(tag string, message []string, file string, opts ...string) []string {
+	args := append([]string{"tag"}, opts...)
+	switch {
+	case t.LocalUser != "":
+		args = append(args, "--local-user", t.LocalUser)
+	case t.Sign:
+		args = append(args, "--sign")
+	}
+	if t.signs() && len(message) == 0 && file == "" {
+		// a signed tag needs a message: prevent to open the editor
+		message = []string{tag}
+	}
 	for _, t := range message {
 		args = append(args, "--message", t)
 	}
 	if file != "" {
 		args = append(args, "--file", file)
 	}
+	return append(args, tag)
+}
 
-	if err := t.run(true, nil, append(args, tag)...); err != nil {
-		return err
+// CreateTag creates the tag at HEAD in the local. Push it with PushTags.
+func (t *Tagger) CreateTag(tag string, message []string, file string) error {
+	return t.run(true, nil, t.tagArgs(tag, message, file)...)
+}
+
+// TagUpdate is a change of a tag in the local, to be pushed or rolled back.
+type TagUpdate struct {
+	Tag string
+	// Old is the object which the tag pointed at before the change: empty for a new tag.
+	Old string
+	// Moved is true if the tag pointed at another commit in the local or the remote.
+	Moved bool
+	// Force makes the remote tag replaced if it points at the Lease (or does not exist for an empty Lease).
+	Force bool
+	Lease string
+	// Delete makes the remote tag deleted if it points at the Lease. It is not pushed for an empty Lease.
+	Delete bool
+}
+
+// MoveTag creates the tag at HEAD, or moves it if it exists, in the local.
+// Push it with PushTags: the remote tag is moved only if it is not changed since it is checked here.
+func (t *Tagger) MoveTag(tag string, message []string, file string) (*TagUpdate, error) {
+	return t.MoveTagTo(tag, "HEAD", message, file)
+}
+
+// MoveTagTo creates or moves the tag to the commit of the revision, like MoveTag.
+func (t *Tagger) MoveTagTo(tag, rev string, message []string, file string) (*TagUpdate, error) {
+	head, err := t.GetCommit(rev)
+	if err != nil {
+		return nil, err
 	}
+	update := &TagUpdate{Tag: tag, Force: true}
+	if old, err := t.getRef("refs/tags/" + tag); err == nil {
+		update.Old = old
+	}
+	if old, err := t.GetCommit("refs/tags/" + tag); err == nil && old != "" {
+		update.Moved = old != head
+	}
+	if t.PushTo != "" {
+		remotes, err := t.GetRemoteTags(t.PushTo, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s in %s: %w", tag, t.PushTo, err)
+		}
+		if len(remotes) > 0 {
+			update.Lease = remotes[0].Object
+			update.Moved = update.Moved || remotes[0].Commit != head
+		}
+	}
+
+	args := t.tagArgs(tag, message, file, "--force")
+	if rev != "HEAD" {
+		args = append(args, head)
+	}
+	if err := t.run(true, nil, args...); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
 
+// RemoveTag deletes the tag in the local, and returns the update to push the deletion with PushTags.
+func (t *Tagger) RemoveTag(tag string) (*TagUpdate, error) {
+	old, err := t.getRef("refs/tags/" + tag)
+	if err != nil {
+		return nil, fmt.Errorf("tag %s not found: %w", tag, err)
+	}
+	update := &TagUpdate{Tag: tag, Old: old, Delete: true, Force: true}
 	if t.PushTo != "" {
-		if err := t.run(true, nil, "push", t.PushTo, tag); err != nil {
-			return err
+		remotes, err := t.GetRemoteTags(t.PushTo, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s in %s: %w", tag, t.PushTo, err)
+		}
+		if len(remotes) > 0 {
+			update.Lease = remotes[0].Object
 		}
 	}
-	return nil
+	if err := t.run(true, nil, "tag", "-d", tag); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// PushTags pushes the tags (and HEAD if head is true) to the PushTo at once (--atomic).
+// It does nothing without the PushTo.
+func (t *Tagger) PushTags(head bool, updates ...*TagUpdate) error {
+	if t.PushTo == "" {
+		return nil
+	}
+	var specs []string
+	args := []string{"push", "--atomic"}
+	for _, u := range updates {
+		switch {
+		case u.Delete && u.Lease == "":
+			// not in the remote
+			continue
+		case u.Delete:
+			specs = append(specs, ":refs/tags/"+u.Tag)
+		default:
+			specs = append(specs, "refs/tags/"+u.Tag)
+		}
+		if u.Force {
+			args = append(args, "--force-with-lease=refs/tags/"+u.Tag+":"+u.Lease)
+		}
+	}
+	if head {
+		specs = append([]string{"HEAD"}, specs...)
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+	args = append(args, t.PushTo)
+	return t.run(true, nil, append(args, specs...)...)
+}
+
+// RollbackTags restores the tags changed in the local: new tags are deleted, and moved ones are put back.
+func (t *Tagger) RollbackTags(updates ...*TagUpdate) error {
+	var errs []error
+	for i := len(updates) - 1; i >= 0; i-- {
+		u := updates[i]
+		var err error
+		if u.Old == "" {
+			err = t.run(true, nil, "tag", "-d", u.Tag)
+		} else {
+			err = t.run(true, nil, "update-ref", "refs/tags/"+u.Tag, u.Old)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", u.Tag, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (t *Tagger) DeleteTag(tag string) error {
@@ -54,14 +222,42 @@ func (t *Tagger) DeleteTag(tag string) error {
 	return nil
 }
 
+// ResetCommit drops the last commit (e.g. created by CommitFiles) keeping the changes staged.
+func (t *Tagger) ResetCommit() error {
+	return t.run(true, nil, "reset", "--soft", "HEAD~1")
+}
+
+// CommitFiles commits the files at HEAD. Push it with PushTags.
+func (t *Tagger) CommitFiles(message string, files []string) error {
+	if err := t.run(true, nil, append([]string{"add", "--"}, files...)...); err != nil {
+		return err
+	}
+	return t.run(true, nil, append([]string{"commit", "--message", message, "--"}, files...)...)
+}
+
 func (t *Tagger) GetTags(fetch bool) ([]string, error) {
+	if t.RemoteOnly != "" {
+		remotes, err := t.GetRemoteTags(t.RemoteOnly)
+		if err != nil {
+			return nil, err
+		}
+		tags := make([]string, 0, len(remotes))
+		for _, r := range remotes {
+			tags = append(tags, r.Name)
+		}
+		return tags, nil
+	}
 	if fetch {
-		if err := t.run(true, nil, "fetch", "--tags"); err != nil {
+		if err := t.FetchTags(); err != nil {
 			return nil, err
 		}
 	}
+	args := []string{"tag", "-l"}
+	if t.Merged != "" {
+		args = append(args, "--merged", t.Merged)
+	}
 	var buf bytes.Buffer
-	if err := t.run(false, &buf, "tag", "-l"); err != nil {
+	if err := t.run(false, &buf, args...); err != nil {
 		return nil, err
 	}
 	var tags []string
@@ -72,7 +268,144 @@ func (t *Tagger) GetTags(fetch bool) ([]string, error) {
 	return tags, nil
 }
 
+// RemoteTag is a tag in a remote repository.
+type RemoteTag struct {
+	Name string
+	// Object is the object which the tag points at: a tag object for an annotated tag.
+	Object string
+	// Commit is the commit which the tag points at.
+	Commit string
+}
+
+// FetchTags fetches tags from the remotes in FetchFrom (or the default remote).
+// Tags are fetched with --force, since the ancestors (e.g. v1 and v1.2) are moved by other clones.
+func (t *Tagger) FetchTags() error {
+	ctx := context.Background()
+	if t.FetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.FetchTimeout)
+		defer cancel()
+	}
+	remotes := t.FetchFrom
+	if len(remotes) == 0 {
+		// the default remote
+		remotes = []string{""}
+	}
+	for _, remote := range remotes {
+		args := []string{"fetch", "--tags", "--force"}
+		if remote != "" {
+			args = append(args, remote)
+		}
+		if err := t.fetchError(ctx, remote, t.runContext(ctx, true, nil, args...)); err != nil {
+			return err
+		}
+	}
+	if !t.PruneTags {
+		return nil
+	}
+	return t.pruneTags(ctx, remotes)
+}
+
+func (t *Tagger) fetchError(ctx context.Context, remote string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if remote != "" {
+		err = fmt.Errorf("%s: %w", remote, err)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("fetching tags timed out after %s: %w", t.FetchTimeout, err)
+	}
+	return err
+}
+
+// pruneTags deletes local tags which exist in none of the remotes.
+// "git fetch --prune-tags" is not used, since it prunes tags of the other remotes.
+func (t *Tagger) pruneTags(ctx context.Context, remotes []string) error {
+	exists := map[string]bool{}
+	for _, remote := range remotes {
+		tags, err := t.remoteTags(ctx, remote)
+		if err != nil {
+			return t.fetchError(ctx, remote, err)
+		}
+		for _, tag := range tags {
+			exists[tag.Name] = true
+		}
+	}
+	var buf bytes.Buffer
+	if err := t.run(false, &buf, "tag", "-l"); err != nil {
+		return err
+	}
+	var stale []string
+	stream := bufio.NewScanner(&buf)
+	for stream.Scan() {
+		if tag := stream.Text(); !exists[tag] {
+			stale = append(stale, tag)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return t.run(true, nil, append([]string{"tag", "-d"}, stale...)...)
+}
+
+// GetRemoteTags lists tags with the names in the remote repository, or all tags if no name is given.
+func (t *Tagger) GetRemoteTags(remote string, names ...string) ([]RemoteTag, error) {
+	return t.remoteTags(context.Background(), remote, names...)
+}
+
+// remoteTags lists tags in the remote (or the default remote if it is empty).
+func (t *Tagger) remoteTags(ctx context.Context, remote string, names ...string) ([]RemoteTag, error) {
+	args := []string{"ls-remote", "--tags"}
+	if remote != "" {
+		args = append(args, remote)
+	}
+	for _, name := range names {
+		args = append(args, "refs/tags/"+name, "refs/tags/"+name+"^{}")
+	}
+	var buf bytes.Buffer
+	if err := t.runContext(ctx, false, &buf, args...); err != nil {
+		return nil, err
+	}
+	var tags []RemoteTag
+	index := map[string]int{}
+	stream := bufio.NewScanner(&buf)
+	for stream.Scan() {
+		fields := strings.SplitN(stream.Text(), "\t", 2)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+		hash, name := fields[0], strings.TrimPrefix(fields[1], "refs/tags/")
+		if peeled := strings.TrimSuffix(name, "^{}"); peeled != name {
+			if i, ok := index[peeled]; ok {
+				tags[i].Commit = hash
+			}
+			continue
+		}
+		index[name] = len(tags)
+		tags = append(tags, RemoteTag{Name: name, Object: hash, Commit: hash})
+	}
+	return tags, nil
+}
+
 func (t *Tagger) GetTagsAtHead() ([]string, error) {
+	if t.RemoteOnly != "" {
+		head, err := t.GetCommit("HEAD")
+		if err != nil {
+			return nil, err
+		}
+		remotes, err := t.GetRemoteTags(t.RemoteOnly)
+		if err != nil {
+			return nil, err
+		}
+		var tags []string
+		for _, r := range remotes {
+			if r.Commit == head {
+				tags = append(tags, r.Name)
+			}
+		}
+		return tags, nil
+	}
 	var buf bytes.Buffer
 	if err := t.run(false, &buf, "tag", "--points-at", "HEAD"); err != nil {
 		return nil, err
@@ -84,3 +417,109 @@ func (t *Tagger) GetTagsAtHead() ([]string, error) {
 	}
 	return tags, nil
 }
+
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+func (c Commit) Subject() string {
+	return firstLine(c.Message)
+}
+
+// GetCommits lists commits in the revision range (e.g. "v1.2.3..HEAD") from the newest one.
+func (t *Tagger) GetCommits(revRange string) ([]Commit, error) {
+	var buf bytes.Buffer
+	if err := t.run(false, &buf, "log", "--format=%H%x1f%B%x1e", revRange); err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, entry := range strings.Split(buf.String(), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(entry, "\n"), "\x1f", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		commits = append(commits, Commit{Hash: fields[0], Message: strings.TrimSpace(fields[1])})
+	}
+	return commits, nil
+}
+
+func (t *Tagger) GetTopLevel() (string, error) {
+	var buf bytes.Buffer
+	if err := t.run(false, &buf, "rev-parse", "--show-toplevel"); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// getRef resolves the object which the ref points at.
+func (t *Tagger) getRef(ref string) (string, error) {
+	var buf bytes.Buffer
+	if err := t.run(false, &buf, "rev-parse", "--verify", "--quiet", ref); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// GetCommit resolves the commit which the rev points at.
+func (t *Tagger) GetCommit(rev string) (string, error) {
+	var buf bytes.Buffer
+	if err := t.run(false, &buf, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// GetTagCommits gets the commits which the tags point at. Tags which are not found are omitted.
+func (t *Tagger) GetTagCommits(tags ...string) (map[string]string, error) {
+	commits := map[string]string{}
+	if t.RemoteOnly != "" {
+		remotes, err := t.GetRemoteTags(t.RemoteOnly)
+		if err != nil {
+			return nil, err
+		}
+		wanted := map[string]bool{}
+		for _, tag := range tags {
+			wanted[tag] = true
+		}
+		for _, r := range remotes {
+			if wanted[r.Name] {
+				commits[r.Name] = r.Commit
+			}
+		}
+		return commits, nil
+	}
+	for _, tag := range tags {
+		if commit, err := t.GetCommit(tag); err == nil {
+			commits[tag] = commit
+		}
+	}
+	return commits, nil
+}
+
+// VerifyTag verifies the signature of the tag.
+func (t *Tagger) VerifyTag(tag string) error {
+	return t.run(false, nil, "tag", "--verify", tag)
+}
+
+// GetConfigBool gets a boolean variable from the git config. It returns false if the variable is not set.
+func (t *Tagger) GetConfigBool(key string) bool {
+	var buf bytes.Buffer
+	if err := t.run(false, &buf, "config", "--type=bool", "--get", key); err != nil {
+		return false
+	}
+	return strings.TrimSpace(buf.String()) == "true"
+}
+
+// GetIdent gets the identity of the committer (e.g. "Name <name@example.com>").
+func (t *Tagger) GetIdent() (string, error) {
+	var buf bytes.Buffer
+	if err := t.run(false, &buf, "var", "GIT_COMMITTER_IDENT"); err != nil {
+		return "", err
+	}
+	ident := strings.TrimSpace(buf.String())
+	if i := strings.LastIndexByte(ident, '>'); i >= 0 {
+		ident = ident[:i+1]
+	}
+	return ident, nil
+}
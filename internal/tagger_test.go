@@ -2,8 +2,12 @@ package internal
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -34,19 +38,37 @@ func TestTagger(t *testing.T) {
 			assert.Equal(t, "git tag --file message.txt dummy\n", buf.String())
 		})
 
-		t.Run("push", func(t *testing.T) {
+		t.Run("sign", func(t *testing.T) {
+			buf, _, tag := tset()
+			tag.Sign = true
+			assert.NoError(t, tag.CreateTag("dummy", []string{"foo"}, ""))
+			assert.Equal(t, "git tag --sign --message foo dummy\n", buf.String())
+		})
+		t.Run("sign without message", func(t *testing.T) {
+			buf, _, tag := tset()
+			tag.Sign = true
+			assert.NoError(t, tag.CreateTag("dummy", nil, ""))
+			assert.Equal(t, "git tag --sign --message dummy dummy\n", buf.String())
+		})
+		t.Run("sign with key", func(t *testing.T) {
+			buf, _, tag := tset()
+			tag.Sign = true
+			tag.LocalUser = "ABCD"
+			assert.NoError(t, tag.CreateTag("dummy", nil, "message.txt"))
+			assert.Equal(t, "git tag --local-user ABCD --file message.txt dummy\n", buf.String())
+		})
+		t.Run("without push", func(t *testing.T) {
 			buf, _, tag := tset()
 			tag.PushTo = "test"
 			assert.NoError(t, tag.CreateTag("dummy", nil, ""))
-			assert.Equal(t, "git tag dummy\ngit push test dummy\n", buf.String())
+			assert.Equal(t, "git tag dummy\n", buf.String())
 		})
 
 		t.Run("workdir", func(t *testing.T) {
 			buf, _, tag := tset()
-			tag.PushTo = "test"
 			tag.Workdir = "dir"
 			assert.NoError(t, tag.CreateTag("dummy", nil, ""))
-			assert.Equal(t, "git -C dir tag dummy\ngit -C dir push test dummy\n", buf.String())
+			assert.Equal(t, "git -C dir tag dummy\n", buf.String())
 		})
 
 	})
@@ -75,6 +97,81 @@ func TestTagger(t *testing.T) {
 
 	})
 
+	t.Run("move tag", func(t *testing.T) {
+		t.Run("new", func(t *testing.T) {
+			buf, run, tag := tset()
+			run.outputs = map[string]io.Reader{"rev-parse --verify --quiet HEAD^{commit}": strings.NewReader("head\n")}
+			run.errs = map[string]error{
+				"rev-parse --verify --quiet refs/tags/v1":          errors.New("exit status 1"),
+				"rev-parse --verify --quiet refs/tags/v1^{commit}": errors.New("exit status 1"),
+			}
+			update, err := tag.MoveTag("v1", nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, &TagUpdate{Tag: "v1", Force: true}, update)
+			assert.Equal(t, "git rev-parse --verify --quiet HEAD^{commit}\n"+
+				"git rev-parse --verify --quiet refs/tags/v1\n"+
+				"git rev-parse --verify --quiet refs/tags/v1^{commit}\n"+
+				"git tag --force v1\n", buf.String())
+		})
+		t.Run("moved", func(t *testing.T) {
+			_, run, tag := tset()
+			run.outputs = map[string]io.Reader{
+				"rev-parse --verify --quiet HEAD^{commit}":         strings.NewReader("head\n"),
+				"rev-parse --verify --quiet refs/tags/v1":          strings.NewReader("obj\n"),
+				"rev-parse --verify --quiet refs/tags/v1^{commit}": strings.NewReader("old\n"),
+			}
+			update, err := tag.MoveTag("v1", nil, "")
+			assert.NoError(t, err)
+			assert.Equal(t, &TagUpdate{Tag: "v1", Old: "obj", Moved: true, Force: true}, update)
+		})
+		t.Run("lease", func(t *testing.T) {
+			buf, run, tag := tset()
+			tag.PushTo = "origin"
+			run.outputs = map[string]io.Reader{
+				"rev-parse --verify --quiet HEAD^{commit}":             strings.NewReader("head\n"),
+				"rev-parse --verify --quiet refs/tags/v1":              strings.NewReader("head\n"),
+				"rev-parse --verify --quiet refs/tags/v1^{commit}":     strings.NewReader("head\n"),
+				"ls-remote --tags origin refs/tags/v1 refs/tags/v1^{}": strings.NewReader("obj\trefs/tags/v1\nold\trefs/tags/v1^{}\n"),
+			}
+			update, err := tag.MoveTag("v1", []string{"foo"}, "")
+			assert.NoError(t, err)
+			assert.Equal(t, &TagUpdate{Tag: "v1", Old: "head", Moved: true, Force: true, Lease: "obj"}, update, "the remote tag is moved")
+			assert.Contains(t, buf.String(), "git tag --force --message foo v1\n")
+			assert.NotContains(t, buf.String(), "git push")
+		})
+	})
+
+	t.Run("push tags", func(t *testing.T) {
+		t.Run("atomic", func(t *testing.T) {
+			buf, _, tag := tset()
+			tag.PushTo = "origin"
+			assert.NoError(t, tag.PushTags(true, &TagUpdate{Tag: "v1.2.3"}, &TagUpdate{Tag: "v1", Force: true, Lease: "obj"}, &TagUpdate{Tag: "v1.2", Force: true}))
+			assert.Equal(t, "git push --atomic --force-with-lease=refs/tags/v1:obj --force-with-lease=refs/tags/v1.2: origin HEAD refs/tags/v1.2.3 refs/tags/v1 refs/tags/v1.2\n", buf.String())
+		})
+		t.Run("without remote", func(t *testing.T) {
+			buf, _, tag := tset()
+			assert.NoError(t, tag.PushTags(false, &TagUpdate{Tag: "v1.2.3"}))
+			assert.Empty(t, buf.String())
+		})
+	})
+
+	t.Run("rollback tags", func(t *testing.T) {
+		buf, run, tag := tset()
+		run.errs = map[string]error{"tag -d v1.2.3": errors.New("exit status 1")}
+		err := tag.RollbackTags(&TagUpdate{Tag: "v1.2.3"}, &TagUpdate{Tag: "v1", Old: "obj", Force: true})
+		assert.ErrorContains(t, err, "v1.2.3")
+		assert.Equal(t, "git update-ref refs/tags/v1 obj\ngit tag -d v1.2.3\n", buf.String())
+	})
+
+	t.Run("get remote tags", func(t *testing.T) {
+		buf, run, tag := tset()
+		run.output = strings.NewReader("aaa\trefs/tags/v1.0.0\nbbb\trefs/tags/v1.1.0\nccc\trefs/tags/v1.1.0^{}\n")
+		tags, err := tag.GetRemoteTags("origin")
+		assert.NoError(t, err)
+		assert.Equal(t, "git ls-remote --tags origin\n", buf.String())
+		assert.Equal(t, []RemoteTag{{Name: "v1.0.0", Object: "aaa", Commit: "aaa"}, {Name: "v1.1.0", Object: "bbb", Commit: "ccc"}}, tags)
+	})
+
 	t.Run("get tag", func(t *testing.T) {
 		t.Run("plain", func(t *testing.T) {
 			buf, run, tag := tset()
@@ -90,17 +187,84 @@ func TestTagger(t *testing.T) {
 			run.output = strings.NewReader("foo\nbar\n")
 			tags, err := tag.GetTags(true)
 			assert.NoError(t, err)
-			assert.Equal(t, "git fetch --tags\ngit tag -l\n", buf.String())
+			assert.Equal(t, "git fetch --tags --force\ngit tag -l\n", buf.String())
 			assert.Equal(t, []string{"foo", "bar"}, tags)
 		})
 
+		t.Run("merged", func(t *testing.T) {
+			buf, run, tag := tset()
+			run.output = strings.NewReader("foo\nbar\n")
+			tag.Merged = "HEAD"
+			tags, err := tag.GetTags(false)
+			assert.NoError(t, err)
+			assert.Equal(t, "git tag -l --merged HEAD\n", buf.String())
+			assert.Equal(t, []string{"foo", "bar"}, tags)
+		})
 		t.Run("fetch in workdir", func(t *testing.T) {
 			buf, run, tag := tset()
 			run.output = strings.NewReader("foo\nbar\n")
 			tag.Workdir = "dir"
 			tags, err := tag.GetTags(true)
 			assert.NoError(t, err)
-			assert.Equal(t, "git -C dir fetch --tags\ngit -C dir tag -l\n", buf.String())
+			assert.Equal(t, "git -C dir fetch --tags --force\ngit -C dir tag -l\n", buf.String())
+			assert.Equal(t, []string{"foo", "bar"}, tags)
+		})
+		t.Run("fetch from remotes", func(t *testing.T) {
+			buf, run, tag := tset()
+			run.output = strings.NewReader("foo\n")
+			tag.FetchFrom = []string{"origin", "upstream"}
+			tags, err := tag.GetTags(true)
+			assert.NoError(t, err)
+			assert.Equal(t, "git fetch --tags --force origin\ngit fetch --tags --force upstream\ngit tag -l\n", buf.String())
+			assert.Equal(t, []string{"foo"}, tags)
+		})
+		t.Run("fetch error", func(t *testing.T) {
+			_, run, tag := tset()
+			run.errs = map[string]error{"fetch --tags --force upstream": errors.New("exit status 128")}
+			tag.FetchFrom = []string{"origin", "upstream"}
+			_, err := tag.GetTags(true)
+			assert.EqualError(t, err, "upstream: exit status 128")
+		})
+		t.Run("fetch timeout", func(t *testing.T) {
+			tag := Tagger{Runner: blockingRunner{}, FetchTimeout: time.Millisecond}
+			err := tag.FetchTags()
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.ErrorContains(t, err, "timed out after 1ms")
+		})
+		t.Run("prune tags", func(t *testing.T) {
+			buf, run, tag := tset()
+			run.outputs = map[string]io.Reader{
+				"ls-remote --tags origin":   strings.NewReader("aaa\trefs/tags/v1.0.0\n"),
+				"ls-remote --tags upstream": strings.NewReader("bbb\trefs/tags/v1.1.0\nccc\trefs/tags/v1.1.0^{}\n"),
+				"tag -l":                    strings.NewReader("v1.0.0\nv1.1.0\nv9.9.9\nexp\n"),
+			}
+			tag.FetchFrom = []string{"origin", "upstream"}
+			tag.PruneTags = true
+			assert.NoError(t, tag.FetchTags())
+			assert.Equal(t, "git fetch --tags --force origin\n"+
+				"git fetch --tags --force upstream\n"+
+				"git ls-remote --tags origin\n"+
+				"git ls-remote --tags upstream\n"+
+				"git tag -l\n"+
+				"git tag -d v9.9.9 exp\n", buf.String())
+		})
+		t.Run("prune tags with the default remote", func(t *testing.T) {
+			buf, run, tag := tset()
+			run.outputs = map[string]io.Reader{
+				"ls-remote --tags": strings.NewReader("aaa\trefs/tags/v1.0.0\n"),
+				"tag -l":           strings.NewReader("v1.0.0\n"),
+			}
+			tag.PruneTags = true
+			assert.NoError(t, tag.FetchTags())
+			assert.Equal(t, "git fetch --tags --force\ngit ls-remote --tags\ngit tag -l\n", buf.String())
+		})
+		t.Run("remote only", func(t *testing.T) {
+			buf, run, tag := tset()
+			run.output = strings.NewReader("aaa\trefs/tags/foo\nbbb\trefs/tags/bar\nccc\trefs/tags/bar^{}\n")
+			tag.RemoteOnly = "origin"
+			tags, err := tag.GetTags(true)
+			assert.NoError(t, err)
+			assert.Equal(t, "git ls-remote --tags origin\n", buf.String())
 			assert.Equal(t, []string{"foo", "bar"}, tags)
 		})
 
@@ -125,5 +289,81 @@ func TestTagger(t *testing.T) {
 			assert.Equal(t, "git -C dir tag --points-at HEAD\n", buf.String())
 			assert.Equal(t, []string{"foo", "bar"}, tags)
 		})
+		t.Run("remote only", func(t *testing.T) {
+			buf, run, tag := tset()
+			run.outputs = map[string]io.Reader{
+				"rev-parse --verify --quiet HEAD^{commit}": strings.NewReader("ccc\n"),
+				"ls-remote --tags origin":                  strings.NewReader("aaa\trefs/tags/foo\nbbb\trefs/tags/bar\nccc\trefs/tags/bar^{}\nccc\trefs/tags/baz\n"),
+			}
+			tag.RemoteOnly = "origin"
+			tags, err := tag.GetTagsAtHead()
+			assert.NoError(t, err)
+			assert.Equal(t, "git rev-parse --verify --quiet HEAD^{commit}\ngit ls-remote --tags origin\n", buf.String())
+			assert.Equal(t, []string{"bar", "baz"}, tags)
+		})
+	})
+	t.Run("get tag commits in the remote", func(t *testing.T) {
+		buf, run, tag := tset()
+		run.output = strings.NewReader("aaa\trefs/tags/foo\nbbb\trefs/tags/bar\nccc\trefs/tags/bar^{}\n")
+		tag.RemoteOnly = "origin"
+		commits, err := tag.GetTagCommits("bar", "qux")
+		assert.NoError(t, err)
+		assert.Equal(t, "git ls-remote --tags origin\n", buf.String())
+		assert.Equal(t, map[string]string{"bar": "ccc"}, commits)
+	})
+
+	t.Run("get commits", func(t *testing.T) {
+		buf, run, tag := tset()
+		run.output = strings.NewReader("aaa\x1ffeat: foo\n\nbody\n\x1e\nbbb\x1ffix: bar\n\x1e\n")
+		commits, err := tag.GetCommits("v1.0.0..HEAD")
+		assert.NoError(t, err)
+		assert.Equal(t, "git log --format=%H%x1f%B%x1e v1.0.0..HEAD\n", buf.String())
+		assert.Equal(t, []Commit{{Hash: "aaa", Message: "feat: foo\n\nbody"}, {Hash: "bbb", Message: "fix: bar"}}, commits)
 	})
+	t.Run("get top level", func(t *testing.T) {
+		buf, run, tag := tset()
+		run.output = strings.NewReader("/path/to/repo\n")
+		top, err := tag.GetTopLevel()
+		assert.NoError(t, err)
+		assert.Equal(t, "git rev-parse --show-toplevel\n", buf.String())
+		assert.Equal(t, "/path/to/repo", top)
+	})
+	t.Run("get commit", func(t *testing.T) {
+		buf, run, tag := tset()
+		run.output = strings.NewReader("0123456789abcdef\n")
+		commit, err := tag.GetCommit("v1.0.0")
+		assert.NoError(t, err)
+		assert.Equal(t, "git rev-parse --verify --quiet v1.0.0^{commit}\n", buf.String())
+		assert.Equal(t, "0123456789abcdef", commit)
+	})
+	t.Run("verify tag", func(t *testing.T) {
+		buf, _, tag := tset()
+		assert.NoError(t, tag.VerifyTag("dummy"))
+		assert.Equal(t, "git tag --verify dummy\n", buf.String())
+	})
+	t.Run("get config bool", func(t *testing.T) {
+		t.Run("true", func(t *testing.T) {
+			buf, run, tag := tset()
+			run.output = strings.NewReader("true\n")
+			assert.True(t, tag.GetConfigBool("tag.gpgSign"))
+			assert.Equal(t, "git config --type=bool --get tag.gpgSign\n", buf.String())
+		})
+		t.Run("unset", func(t *testing.T) {
+			_, run, tag := tset()
+			run.errs = map[string]error{"config --type=bool --get tag.gpgSign": errors.New("exit status 1")}
+			assert.False(t, tag.GetConfigBool("tag.gpgSign"))
+		})
+	})
+}
+
+// blockingRunner runs commands until the context is done.
+type blockingRunner struct{}
+
+func (blockingRunner) Run(sideEffects bool, stdout io.Writer, args ...string) error {
+	return nil
+}
+
+func (blockingRunner) RunContext(ctx context.Context, sideEffects bool, stdout io.Writer, args ...string) error {
+	<-ctx.Done()
+	return ctx.Err()
 }
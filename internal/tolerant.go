@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// TolerantSemVer is the Semantic Versioning which accepts any number of release numbers (e.g. "1.2" or "1.2.3.4").
+// The pre-release and build notation are of the SemVer, and bumped versions keep the number of release numbers.
+type TolerantSemVer struct{}
+
+var tolerantPattern = regexp.MustCompile(`^(\d+(?:\.\d+)*)(?:-([0-9A-Za-z.-]+))?(?:\+([0-9A-Za-z.-]+))?$`)
+
+func (TolerantSemVer) Parse(s string) (Ver, bool) {
+	m := tolerantPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	v := tolerantVer{text: s}
+	for _, f := range strings.Split(m[1], ".") {
+		n, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		v.nums = append(v.nums, n)
+	}
+	if m[2] != "" {
+		for _, p := range strings.Split(m[2], ".") {
+			pr, err := semver.NewPRVersion(p)
+			if err != nil {
+				return nil, false
+			}
+			v.pre = append(v.pre, pr)
+		}
+	}
+	if m[3] != "" {
+		for _, b := range strings.Split(m[3], ".") {
+			if _, err := semver.NewBuildVersion(b); err != nil {
+				return nil, false
+			}
+			v.build = append(v.build, b)
+		}
+	}
+	return v, true
+}
+
+func (TolerantSemVer) Zero() Ver {
+	return tolerantVer{nums: []uint64{0, 0, 0}}
+}
+
+// Bump increments the release numbers by the level, and changes the pre-release and build notation like the SemVer.
+func (s TolerantSemVer) Bump(cur Ver, c Change) (Ver, error) {
+	v, ok := cur.(tolerantVer)
+	if !ok {
+		v = s.Zero().(tolerantVer)
+	}
+	next := tolerantVer{nums: v.nums}
+	notation := semver.Version{Pre: v.pre, Build: v.build}
+	if level := releaseLevel(c.Kind); level >= 0 {
+		next.nums = bumpRelease(v.nums, level)
+		notation = semver.Version{}
+	}
+	// the Updater changes the notation on the numbers which are not used
+	n, err := bumpSemver(notation, c)
+	if err != nil {
+		return nil, err
+	}
+	next.pre, next.build = n.Pre, n.Build
+	return next, nil
+}
+
+// tolerantVer is a version of the TolerantSemVer.
+type tolerantVer struct {
+	nums  []uint64
+	pre   []semver.PRVersion
+	build []string
+	// text is the version as it is in the tag, which may have leading zeros.
+	text string
+}
+
+func (v tolerantVer) String() string {
+	if v.text != "" {
+		return v.text
+	}
+	s := formatRelease(v.nums)
+	if len(v.pre) > 0 {
+		s += "-" + formatPre(v.pre)
+	}
+	if len(v.build) > 0 {
+		s += "+" + strings.Join(v.build, ".")
+	}
+	return s
+}
+
+func (v tolerantVer) Compare(o Ver) int {
+	w, ok := o.(tolerantVer)
+	if !ok {
+		return v.Semver().Compare(o.Semver())
+	}
+	if c := compareRelease(v.nums, w.nums); c != 0 {
+		return c
+	}
+	return semver.Version{Pre: v.pre}.Compare(semver.Version{Pre: w.pre})
+}
+
+func (v tolerantVer) Semver() semver.Version {
+	s := releaseSemver(v.nums)
+	s.Pre, s.Build = v.pre, v.build
+	return s
+}
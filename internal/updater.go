@@ -2,12 +2,13 @@ package internal
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/blang/semver/v4"
 )
 
 func NewUpdater(v semver.Version) Updater {
-	return &implUpdater{ver: v, pre: len(v.Pre) > 0}
+	return &implUpdater{ver: v, pre: len(v.Pre) > 0, from: v}
 }
 
 // Updater will update version without rewinds.
@@ -36,6 +37,9 @@ type Version interface {
 type implUpdater struct {
 	ver semver.Version
 	pre bool
+	// from is the version to update, which put pre-release IDs must not rewind.
+	from   semver.Version
+	putPre bool
 }
 
 func (i implUpdater) Major() UpdatePre {
@@ -43,7 +47,8 @@ func (i implUpdater) Major() UpdatePre {
 		ver: semver.Version{
 			Major: i.ver.Major + 1,
 		},
-		pre: true,
+		pre:  true,
+		from: i.from,
 	}
 }
 
@@ -53,7 +58,8 @@ func (i implUpdater) Minor() UpdatePre {
 			Major: i.ver.Major,
 			Minor: i.ver.Minor + 1,
 		},
-		pre: true,
+		pre:  true,
+		from: i.from,
 	}
 }
 
@@ -80,7 +86,7 @@ func incrementPre(pre []semver.PRVersion) []semver.PRVersion {
 	}
 	ret := make([]semver.PRVersion, len(pre)+1)
 	copy(ret, pre)
-	ret[len(pre)] = semver.PRVersion{VersionNum: 2}
+	ret[len(pre)] = semver.PRVersion{VersionNum: 2, IsNum: true}
 	return ret
 }
 
@@ -92,6 +98,7 @@ func (i implUpdater) Pre(p ...semver.PRVersion) UpdateBuild {
 		next.ver.Pre = p
 	}
 	next.ver.Build = nil
+	next.putPre = true
 	return next
 }
 
@@ -112,5 +119,8 @@ func (i implUpdater) Version() (semver.Version, error) {
 	if !i.pre && len(i.ver.Pre) > 0 {
 		return semver.Version{}, errors.New("putting pre-release ID rewinds version order")
 	}
+	if i.putPre && len(i.ver.Pre) > 0 && i.ver.Compare(i.from) <= 0 {
+		return semver.Version{}, fmt.Errorf("putting pre-release ID rewinds version order from %s to %s", i.from, i.ver)
+	}
 	return i.ver, nil
 }
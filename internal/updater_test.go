@@ -18,7 +18,7 @@ func mustPRVer(t *testing.T, s string) semver.PRVersion {
 	return v
 }
 
-func TextIncrementPre(t *testing.T) {
+func TestIncrementPre(t *testing.T) {
 	t.Run("increment pre-release", func(t *testing.T) {
 		version, err := semver.Parse("1.2.3-pre-release.4+build-ver.5")
 		assert.NoError(t, err)
@@ -67,18 +67,28 @@ func TestUpdater(t *testing.T) {
 			version, err := semver.Parse("1.2.3-pre-release.4+build-ver.5")
 			assert.NoError(t, err)
 			v, err := NewUpdater(version).
-				Pre(mustPRVer(t, "beta"), mustPRVer(t, "6")).
+				Pre(mustPRVer(t, "rc"), mustPRVer(t, "6")).
 				Build(("build-ver"), ("7")).Version()
 			assert.NoError(t, err)
-			assert.Equal(t, "1.2.3-beta.6+build-ver.7", v.String())
+			assert.Equal(t, "1.2.3-rc.6+build-ver.7", v.String())
 		})
 		t.Run("set pre-release", func(t *testing.T) {
 			version, err := semver.Parse("1.2.3-pre-release.4+build-ver.5")
 			assert.NoError(t, err)
 			v, err := NewUpdater(version).
-				Pre(mustPRVer(t, "beta"), mustPRVer(t, "6")).Version()
+				Pre(mustPRVer(t, "rc"), mustPRVer(t, "6")).Version()
 			assert.NoError(t, err)
-			assert.Equal(t, "1.2.3-beta.6", v.String())
+			assert.Equal(t, "1.2.3-rc.6", v.String())
+		})
+		t.Run("set pre-release which rewinds", func(t *testing.T) {
+			version, err := semver.Parse("1.2.3-pre-release.4+build-ver.5")
+			assert.NoError(t, err)
+			_, err = NewUpdater(version).
+				Pre(mustPRVer(t, "beta"), mustPRVer(t, "6")).Version()
+			assert.Error(t, err)
+			_, err = NewUpdater(version).
+				Pre(mustPRVer(t, "pre-release"), mustPRVer(t, "4")).Version()
+			assert.Error(t, err)
 		})
 		t.Run("increment pre-release", func(t *testing.T) {
 			version, err := semver.Parse("1.2.3-pre-release.4+build-ver.5")
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// Ver is a version in version tags, made by a Scheme.
+type Ver interface {
+	// String formats the version as it is in tag names, without the prefix and the suffix.
+	String() string
+	// Compare returns -1, 0 or +1 if the version is lower than, equal to or higher than o of the same scheme.
+	Compare(o Ver) int
+	// Semver is the version as a SemVer, to check it with ranges and to report its numbers.
+	Semver() semver.Version
+}
+
+// SemVer is a version of the Semantic Versioning.
+type SemVer semver.Version
+
+func (v SemVer) String() string {
+	return semver.Version(v).String()
+}
+
+func (v SemVer) Compare(o Ver) int {
+	return semver.Version(v).Compare(o.Semver())
+}
+
+func (v SemVer) Semver() semver.Version {
+	return semver.Version(v)
+}
+
+// Vers are versions to be sorted in ascending order.
+type Vers []Ver
+
+func (s Vers) Len() int           { return len(s) }
+func (s Vers) Less(i, j int) bool { return s[i].Compare(s[j]) < 0 }
+func (s Vers) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func isPre(v Ver) bool {
+	return len(v.Semver().Pre) > 0
+}
+
+// compareRelease compares release numbers like "1.2" and "1.2.0.1": missing numbers are zeros.
+func compareRelease(a, b []uint64) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y uint64
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// releaseSemver maps the first three release numbers to the major, minor and patch numbers.
+func releaseSemver(nums []uint64) semver.Version {
+	var v [3]uint64
+	copy(v[:], nums)
+	return semver.Version{Major: v[0], Minor: v[1], Patch: v[2]}
+}
+
+func formatRelease(nums []uint64) string {
+	fields := make([]string, len(nums))
+	for i, n := range nums {
+		fields[i] = strconv.FormatUint(n, 10)
+	}
+	return strings.Join(fields, ".")
+}
+
+func formatPre(pre []semver.PRVersion) string {
+	ids := make([]string, len(pre))
+	for i, p := range pre {
+		ids[i] = p.String()
+	}
+	return strings.Join(ids, ".")
+}
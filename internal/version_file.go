@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Types of the VersionFile.
+const (
+	VersionFileJSON  = "json"
+	VersionFileYAML  = "yaml"
+	VersionFileRegex = "regex"
+	VersionFileGo    = "go"
+)
+
+var ErrVersionField = errors.New("version field not found")
+
+// VersionFile is a file which has a version field to be synced with the version tag.
+type VersionFile struct {
+	// Type is the handler of the file: "json", "yaml", "regex" or "go".
+	Type string `toml:"type"`
+	Path string `toml:"path"`
+	// Key locates the version field by the type:
+	// a dot-separated path of keys for "json" and "yaml" ("version" by default),
+	// a regular expression whose first group is the version for "regex",
+	// and the name of the constant for "go" ("Version" by default).
+	Key string `toml:"key"`
+}
+
+// ParseVersionFile parses a spec of a VersionFile like "json:package.json" or "yaml:Chart.yaml:appVersion".
+func ParseVersionFile(spec string) (VersionFile, error) {
+	terms := strings.SplitN(spec, ":", 3)
+	if len(terms) < 2 || terms[1] == "" {
+		return VersionFile{}, fmt.Errorf("invalid version file %q: it should be TYPE:PATH[:KEY]", spec)
+	}
+	f := VersionFile{Type: terms[0], Path: terms[1]}
+	if len(terms) == 3 {
+		f.Key = terms[2]
+	}
+	return f, f.validate()
+}
+
+func (f VersionFile) validate() error {
+	switch f.Type {
+	case VersionFileJSON, VersionFileYAML, VersionFileGo:
+	case VersionFileRegex:
+		re, err := regexp.Compile(f.Key)
+		if err != nil {
+			return fmt.Errorf("invalid regex for %s: %w", f.Path, err)
+		}
+		if re.NumSubexp() < 1 {
+			return fmt.Errorf("invalid regex for %s: it needs a group for the version", f.Path)
+		}
+	default:
+		return fmt.Errorf("invalid type of the version file %s: %q", f.Path, f.Type)
+	}
+	return nil
+}
+
+func (f VersionFile) key(def string) string {
+	if f.Key == "" {
+		return def
+	}
+	return f.Key
+}
+
+// Write rewrites the version field in the file. It returns false if the field has the version already.
+func (f VersionFile) Write(version string) (bool, error) {
+	if err := f.validate(); err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return false, err
+	}
+	var start, end int
+	var value string
+	switch f.Type {
+	case VersionFileJSON:
+		start, end, err = jsonField(data, strings.Split(f.key("version"), "."))
+		value = strconv.Quote(version)
+	case VersionFileYAML:
+		start, end, value, err = yamlField(data, strings.Split(f.key("version"), "."), version)
+	case VersionFileRegex:
+		start, end, err = regexField(data, f.Key)
+		value = version
+	case VersionFileGo:
+		start, end, err = goConst(data, f.key("Version"))
+		value = strconv.Quote(version)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", f.Path, err)
+	}
+	if string(data[start:end]) == value {
+		return false, nil
+	}
+	var buf bytes.Buffer
+	buf.Write(data[:start])
+	buf.WriteString(value)
+	buf.Write(data[end:])
+	return true, writeFileKeepMode(f.Path, buf.Bytes())
+}
+
+// jsonField finds the range of the string value at the path.
+func jsonField(data []byte, path []string) (int, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var walk func(depth int) (int, int, error)
+	walk = func(depth int) (int, int, error) {
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, 0, err
+		}
+		if tok != json.Delim('{') {
+			return 0, 0, ErrVersionField
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return 0, 0, err
+			}
+			if key != path[depth] {
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					return 0, 0, err
+				}
+				continue
+			}
+			if depth+1 < len(path) {
+				return walk(depth + 1)
+			}
+			offset := int(dec.InputOffset())
+			value, err := dec.Token()
+			if err != nil {
+				return 0, 0, err
+			}
+			if _, ok := value.(string); !ok {
+				return 0, 0, fmt.Errorf("%w: %s is not a string", ErrVersionField, strings.Join(path, "."))
+			}
+			end := int(dec.InputOffset())
+			start := offset + bytes.IndexByte(data[offset:end], '"')
+			return start, end, nil
+		}
+		return 0, 0, ErrVersionField
+	}
+	start, end, err := walk(0)
+	if err == io.EOF {
+		return 0, 0, ErrVersionField
+	}
+	return start, end, err
+}
+
+// yamlField finds the range of the scalar value at the path, and formats the version in the same style.
+func yamlField(data []byte, path []string, version string) (int, int, string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, 0, "", err
+	}
+	if len(doc.Content) == 0 {
+		return 0, 0, "", ErrVersionField
+	}
+	node := doc.Content[0]
+	for _, key := range path {
+		if node.Kind != yaml.MappingNode {
+			return 0, 0, "", ErrVersionField
+		}
+		var found *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				found = node.Content[i+1]
+			}
+		}
+		if found == nil {
+			return 0, 0, "", ErrVersionField
+		}
+		node = found
+	}
+	if node.Kind != yaml.ScalarNode {
+		return 0, 0, "", fmt.Errorf("%w: %s is not a scalar", ErrVersionField, strings.Join(path, "."))
+	}
+
+	// find the offset of the line and the column
+	start := 0
+	for line := 1; line < node.Line; line++ {
+		i := bytes.IndexByte(data[start:], '\n')
+		if i < 0 {
+			return 0, 0, "", ErrVersionField
+		}
+		start += i + 1
+	}
+	for col := 1; col < node.Column && start < len(data); col++ {
+		_, size := utf8.DecodeRune(data[start:])
+		start += size
+	}
+
+	var raw, value string
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		raw, value = strconv.Quote(node.Value), strconv.Quote(version)
+	case yaml.SingleQuotedStyle:
+		raw, value = "'"+node.Value+"'", "'"+version+"'"
+	case 0:
+		raw, value = node.Value, version
+	default:
+		return 0, 0, "", fmt.Errorf("%w: unsupported style of %s", ErrVersionField, strings.Join(path, "."))
+	}
+	if !bytes.HasPrefix(data[start:], []byte(raw)) {
+		return 0, 0, "", fmt.Errorf("%w: unsupported notation of %s", ErrVersionField, strings.Join(path, "."))
+	}
+	return start, start + len(raw), value, nil
+}
+
+// regexField finds the range of the first group in the first match.
+func regexField(data []byte, expr string) (int, int, error) {
+	loc := regexp.MustCompile(expr).FindSubmatchIndex(data)
+	if loc == nil || loc[2] < 0 {
+		return 0, 0, ErrVersionField
+	}
+	return loc[2], loc[3], nil
+}
+
+// goConst finds the range of the string literal of the constant.
+func goConst(data []byte, name string) (int, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", data, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ValueSpec)
+			for i, ident := range spec.Names {
+				if ident.Name != name || i >= len(spec.Values) {
+					continue
+				}
+				lit, ok := spec.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return 0, 0, fmt.Errorf("%w: %s is not a string literal", ErrVersionField, name)
+				}
+				return fset.Position(lit.Pos()).Offset, fset.Position(lit.End()).Offset, nil
+			}
+		}
+	}
+	return 0, 0, ErrVersionField
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersionFile(t *testing.T) {
+	f, err := ParseVersionFile("json:package.json")
+	require.NoError(t, err)
+	assert.Equal(t, VersionFile{Type: VersionFileJSON, Path: "package.json"}, f)
+
+	f, err = ParseVersionFile(`regex:VERSION:version=(\S+)`)
+	require.NoError(t, err)
+	assert.Equal(t, VersionFile{Type: VersionFileRegex, Path: "VERSION", Key: `version=(\S+)`}, f)
+
+	_, err = ParseVersionFile("package.json")
+	assert.Error(t, err)
+	_, err = ParseVersionFile("toml:Cargo.toml")
+	assert.Error(t, err)
+	_, err = ParseVersionFile("regex:VERSION:version")
+	assert.Error(t, err, "regex without group")
+}
+
+func TestVersionFile(t *testing.T) {
+	write := func(t *testing.T, f VersionFile, content string) (string, bool, error) {
+		t.Helper()
+		f.Path = filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(f.Path, []byte(content), 0644))
+		changed, err := f.Write("1.3.0")
+		data, rerr := os.ReadFile(f.Path)
+		require.NoError(t, rerr)
+		return string(data), changed, err
+	}
+
+	t.Run("json", func(t *testing.T) {
+		got, changed, err := write(t, VersionFile{Type: VersionFileJSON}, `{
+  "name": "foo",
+  "dependencies": {"version": "0.1.0"},
+  "version" : "1.2.3"
+}
+`)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, `{
+  "name": "foo",
+  "dependencies": {"version": "0.1.0"},
+  "version" : "1.3.0"
+}
+`, got)
+	})
+	t.Run("json path", func(t *testing.T) {
+		got, _, err := write(t, VersionFile{Type: VersionFileJSON, Key: "packages.foo"}, `{"packages": {"bar": "1.0.0", "foo": "1.2.3"}}`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"packages": {"bar": "1.0.0", "foo": "1.3.0"}}`, got)
+	})
+	t.Run("json without field", func(t *testing.T) {
+		_, _, err := write(t, VersionFile{Type: VersionFileJSON}, `{"name": "foo"}`)
+		assert.ErrorIs(t, err, ErrVersionField)
+	})
+	t.Run("json unchanged", func(t *testing.T) {
+		_, changed, err := write(t, VersionFile{Type: VersionFileJSON}, `{"version": "1.3.0"}`)
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		got, changed, err := write(t, VersionFile{Type: VersionFileYAML}, `apiVersion: v2
+name: foo # the chart
+version: 1.2.3 # the chart version
+appVersion: "1.2.3"
+`)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, `apiVersion: v2
+name: foo # the chart
+version: 1.3.0 # the chart version
+appVersion: "1.2.3"
+`, got)
+	})
+	t.Run("yaml quoted", func(t *testing.T) {
+		got, _, err := write(t, VersionFile{Type: VersionFileYAML, Key: "appVersion"}, "version: 1.2.3\nappVersion: \"1.2.3\"\n")
+		require.NoError(t, err)
+		assert.Equal(t, "version: 1.2.3\nappVersion: \"1.3.0\"\n", got)
+	})
+	t.Run("yaml nested", func(t *testing.T) {
+		got, _, err := write(t, VersionFile{Type: VersionFileYAML, Key: "image.tag"}, "image:\n  repo: foo\n  tag: '1.2.3'\n")
+		require.NoError(t, err)
+		assert.Equal(t, "image:\n  repo: foo\n  tag: '1.3.0'\n", got)
+	})
+	t.Run("yaml without field", func(t *testing.T) {
+		_, _, err := write(t, VersionFile{Type: VersionFileYAML}, "name: foo\n")
+		assert.ErrorIs(t, err, ErrVersionField)
+	})
+
+	t.Run("regex", func(t *testing.T) {
+		got, changed, err := write(t, VersionFile{Type: VersionFileRegex, Key: `(?m)^VERSION\s*:?=\s*(\S+)`}, "NAME := foo\nVERSION := 1.2.3\n")
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, "NAME := foo\nVERSION := 1.3.0\n", got)
+	})
+	t.Run("regex without match", func(t *testing.T) {
+		_, _, err := write(t, VersionFile{Type: VersionFileRegex, Key: `VERSION=(\S+)`}, "NAME=foo\n")
+		assert.ErrorIs(t, err, ErrVersionField)
+	})
+
+	t.Run("go", func(t *testing.T) {
+		got, changed, err := write(t, VersionFile{Type: VersionFileGo}, `package foo
+
+const (
+	Name    = "foo"
+	Version = "1.2.3" // the version
+)
+`)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, `package foo
+
+const (
+	Name    = "foo"
+	Version = "1.3.0" // the version
+)
+`, got)
+	})
+	t.Run("go var", func(t *testing.T) {
+		_, _, err := write(t, VersionFile{Type: VersionFileGo}, "package foo\n\nvar Version = \"1.2.3\"\n")
+		assert.ErrorIs(t, err, ErrVersionField)
+	})
+}
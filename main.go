@@ -1,12 +1,19 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kingpin"
 	"github.com/kyoh86/git-vertag/internal"
+	"golang.org/x/term"
 )
 
 // nolint
@@ -26,92 +33,408 @@ func main() {
 	var cwd string
 	var dryRun bool
 	var fetch bool
+	var fetchFrom []string
+	var pruneTags bool
+	var fetchTimeout time.Duration
 	var prefix string
+	var pattern string
+	var calver string
+	var schemeName string
 	var ancestors bool
+	var moduleDir string
+	var reachable bool
+	var backend string
+	var output string
 	app.Flag("current-directory", "Run as if git was started in <path> instead of the current working directory.").Short('C').PlaceHolder("<path>").ExistingDirVar(&cwd)
+	app.Flag("backend", "Git implementation: \"git\" runs the git command, \"go-git\" runs in-process without it.").Envar("GIT_VERTAG_BACKEND").Default("git").EnumVar(&backend, "git", "go-git")
+	app.Flag("output", "Output format (text or json).").Short('o').Envar("GIT_VERTAG_OUTPUT").Default("text").EnumVar(&output, "text", "json")
 	app.Flag("dry-run", "Without creating nor deleting tag, show git command.").Envar("GIT_VERTAG_DRYRUN").BoolVar(&dryRun)
-	app.Flag("fetch", "Fetch tags first").Envar("GIT_VERTAG_FETCH").Default("true").BoolVar(&fetch)
-	app.Flag("prefix", "Prefix for tag").Envar("GIT_VERTAG_PREFIX").Default("v").StringVar(&prefix)
-	app.Flag("ancestors", "With ancestor versions (vN and vN.N)").Envar("GIT_VERTAG_ANCESTORS").BoolVar(&ancestors)
+	fetchFlag := app.Flag("fetch", "Fetch tags first").Envar("GIT_VERTAG_FETCH").Default("true")
+	fetchFlag.BoolVar(&fetch)
+	fetchFromFlag := app.Flag("fetch-from", "The remote to fetch tags from. If multiple --fetch-from options are given, tags are fetched from each of them.").PlaceHolder("REPOSITORY")
+	fetchFromFlag.StringsVar(&fetchFrom)
+	pruneTagsFlag := app.Flag("prune-tags", "Delete local tags which do not exist in the remote when fetching tags.").Envar("GIT_VERTAG_PRUNE_TAGS")
+	pruneTagsFlag.BoolVar(&pruneTags)
+	app.Flag("fetch-timeout", "Give up fetching tags after the duration (e.g. 30s).").Envar("GIT_VERTAG_FETCH_TIMEOUT").PlaceHolder("DURATION").DurationVar(&fetchTimeout)
+	prefixFlag := app.Flag("prefix", "Prefix for tag").Envar("GIT_VERTAG_PREFIX").Default("v")
+	prefixFlag.StringVar(&prefix)
+	patternFlag := app.Flag("pattern", "Pattern of tag names with "+internal.PatternVersion+" (and "+internal.PatternModule+" for --module) e.g. \"release-{{version}}\". It takes precedence over --prefix.").Envar("GIT_VERTAG_PATTERN").PlaceHolder("PATTERN")
+	patternFlag.StringVar(&pattern)
+	calverFlag := app.Flag("calver", "Use the Calendar Versioning in the format (e.g. YYYY.MM.MICRO or YY.0M.0D.N) instead of the Semantic Versioning.").Envar("GIT_VERTAG_CALVER").PlaceHolder("FORMAT")
+	calverFlag.StringVar(&calver)
+	schemeFlag := app.Flag("scheme", "Version scheme of tags: semver, semver-tolerant (e.g. v1.2 or v1.2.3.4) or pep440 (e.g. v1.2.0rc1).").Envar("GIT_VERTAG_SCHEME").Default("semver")
+	schemeFlag.EnumVar(&schemeName, "semver", "semver-tolerant", "pep440")
+	ancestorsFlag := app.Flag("ancestors", "With ancestor versions (vN and vN.N)").Envar("GIT_VERTAG_ANCESTORS")
+	ancestorsFlag.BoolVar(&ancestors)
+	app.Flag("reachable", "Consider only tags reachable from HEAD (or --from).").Envar("GIT_VERTAG_REACHABLE").BoolVar(&reachable)
+	app.Flag("module", "Manage tags of the Go module in <dir> (e.g. sub/dir/v1.2.3). The prefix is put after the module directory.").Envar("GIT_VERTAG_MODULE").PlaceHolder("<dir>").StringVar(&moduleDir)
 
 	getCmd := app.Command("get", "Gets the current version tag.").Default()
 	validateCmd := app.Command("validate", "Validates a version tag.")
-	deleteCmd := app.Command("delete", "Deletes the current version tag.")
+	deleteCmd := app.Command("delete", "Deletes the current version tag, or the given tags and versions in the range.")
 	majorCmd := app.Command("major", "Creates a tag for the next major version and prints it.")
 	minorCmd := app.Command("minor", "Creates a tag for the next minor version and prints it.")
 	patchCmd := app.Command("patch", "Creates a tag for the next patch version and prints it.")
 	releaseCmd := app.Command("release", "Creates a tag to remove pre-release meta information.")
 	preCmd := app.Command("pre", "Creates a tag for the next pre-release version and prints it.")
 	buildCmd := app.Command("build", "Creates a tag for the next build version and prints it.")
+	postCmd := app.Command("post", "Creates a tag for the next post-release version (PEP 440) and prints it.")
+	devCmd := app.Command("dev", "Creates a tag for the next development release version (PEP 440) and prints it.")
+	satisfiesCmd := app.Command("satisfies", "Checks the current version (or a tag) with a constraint. It exits with 1 if unsatisfied, or 2 on errors.")
+	listCmd := app.Command("list", "Lists version tags in order of the versions.")
+	changelogCmd := app.Command("changelog", "Prints release notes between version tags.")
+	autoCmd := app.Command("auto", "Creates a tag for the next version decided from the Conventional Commits since the current version and prints it.")
 
 	var message []string
 	var file string
 	var pushTo string
+	var pushToFlags []*kingpin.FlagClause
+	var sign bool
+	var localUser string
+	var messageTemplate string
+	var messageTemplateFlags []*kingpin.FlagClause
 
-	for _, c := range []*kingpin.CmdClause{majorCmd, minorCmd, patchCmd, preCmd, buildCmd, releaseCmd} {
+	for _, c := range []*kingpin.CmdClause{majorCmd, minorCmd, patchCmd, preCmd, buildCmd, postCmd, devCmd, releaseCmd, autoCmd} {
+		f := c.Flag("push-to", "The remote repository that is destination of a push operation. This parameter can be either a URL or the name of a remote.").Envar("GIT_VERTAG_PUSH_TO").PlaceHolder("REPOSITORY")
+		f.StringVar(&pushTo)
+		pushToFlags = append(pushToFlags, f)
+	}
+	// delete moves ancestors back with the tag messages and the signature as updates do
+	for _, c := range []*kingpin.CmdClause{deleteCmd, majorCmd, minorCmd, patchCmd, preCmd, buildCmd, postCmd, devCmd, releaseCmd, autoCmd} {
 		c.Flag("message", "Use the given tag message (instead of prompting). If multiple -m options are given, their values are concatenated as separate paragraphs.").Short('m').StringsVar(&message)
 		c.Flag("file", "Take the tag message from the given file. Use - to read the message from the standard input").Short('F').StringVar(&file)
-		c.Flag("push-to", "The remote repository that is destination of a push operation. This parameter can be either a URL or the name of a remote.").PlaceHolder("REPOSITORY").StringVar(&pushTo)
+		tf := c.Flag("message-template", "Render the tag message with the text/template, unless --message or --file is given. e.g. \"{{.Next}}{{range .Shortlog}}\\n- {{.Subject}}{{end}}\"").Envar("GIT_VERTAG_MESSAGE_TEMPLATE").PlaceHolder("TEMPLATE")
+		tf.StringVar(&messageTemplate)
+		messageTemplateFlags = append(messageTemplateFlags, tf)
+		c.Flag("sign", "Make a signed tag, using the default signing key (GPG, or SSH with gpg.format=ssh). It is enabled by tag.gpgSign in the git config.").Short('s').BoolVar(&sign)
+		c.Flag("local-user", "Make a signed tag, using the given key.").Short('u').PlaceHolder("KEY-ID").StringVar(&localUser)
+	}
+	deletePushTo := deleteCmd.Flag("push-to", "The remote repository to delete the tags in. This parameter can be either a URL or the name of a remote.").Envar("GIT_VERTAG_PUSH_TO").PlaceHolder("REPOSITORY")
+	deletePushTo.StringVar(&pushTo)
+	pushToFlags = append(pushToFlags, deletePushTo)
+
+	var from string
+	for _, c := range []*kingpin.CmdClause{getCmd, listCmd, satisfiesCmd, deleteCmd, majorCmd, minorCmd, patchCmd, preCmd, buildCmd, postCmd, devCmd, releaseCmd, autoCmd} {
+		c.Flag("from", "Consider only tags reachable from the revision (implies --reachable). Unlike \"changelog --from\", it is a revision to look from, not a tag to start the range.").PlaceHolder("REV").StringVar(&from)
+	}
+
+	var remoteOnly bool
+	var remote string
+	for _, c := range []*kingpin.CmdClause{getCmd, validateCmd, listCmd, satisfiesCmd} {
+		c.Flag("remote-only", "Read tags from the remote by ls-remote instead of fetching them: nothing in the local repository is changed.").Envar("GIT_VERTAG_REMOTE_ONLY").BoolVar(&remoteOnly)
+		c.Flag("remote", "The remote repository to read tags from with --remote-only.").Envar("GIT_VERTAG_REMOTE").Default("origin").PlaceHolder("REPOSITORY").StringVar(&remote)
+	}
+
+	var noHooks bool
+	for _, c := range []*kingpin.CmdClause{deleteCmd, majorCmd, minorCmd, patchCmd, preCmd, buildCmd, postCmd, devCmd, releaseCmd, autoCmd} {
+		c.Flag("no-hooks", "Bypass the hooks in "+filepath.ToSlash(internal.HookDir)+" and the config.").BoolVar(&noHooks)
+	}
+
+	var writeFiles bool
+	for _, c := range []*kingpin.CmdClause{majorCmd, minorCmd, patchCmd, preCmd, buildCmd, postCmd, devCmd, releaseCmd, autoCmd} {
+		c.Flag("write-files", "Write the next version into the files in the config (e.g. package.json), and commit them to be tagged.").Envar("GIT_VERTAG_WRITE_FILES").BoolVar(&writeFiles)
+	}
+
+	var retry int
+	for _, c := range []*kingpin.CmdClause{majorCmd, minorCmd, patchCmd, preCmd, buildCmd, postCmd, devCmd, releaseCmd, autoCmd} {
+		c.Flag("retry", "Retry up to the number of times when another run pushed the next version first: fetch tags, compute the version again and tag it.").Envar("GIT_VERTAG_RETRY").PlaceHolder("N").IntVar(&retry)
+	}
+
+	var rewriteModule bool
+	for _, c := range []*kingpin.CmdClause{majorCmd, autoCmd} {
+		c.Flag("rewrite-module", "Rewrite the module path in go.mod and its imports for the new major version (e.g. /v2) instead of failing. The tag is not created: commit the changes and run again.").BoolVar(&rewriteModule)
 	}
 
 	var pre internal.PreReleaseFlag
-	for _, c := range []*kingpin.CmdClause{majorCmd, minorCmd, patchCmd} {
-		c.Flag("pre", "Update pre-release notation. It accepts only alphanumeric or numeric identities.").SetValue(&pre)
+	var preFlags []*kingpin.FlagClause
+	for _, c := range []*kingpin.CmdClause{majorCmd, minorCmd, patchCmd, autoCmd} {
+		f := c.Flag("pre", "Update pre-release notation. It accepts only alphanumeric or numeric identities.")
+		f.SetValue(&pre)
+		preFlags = append(preFlags, f)
 	}
 	preCmd.Arg("pre", "Pre-release notation. It accepts only alphanumeric or numeric identities.").SetValue(&pre)
+	var promote bool
+	var channels []string
+	preCmd.Flag("promote", "Move to the first pre-release in the next channel (e.g. from alpha.4 to beta.1).").BoolVar(&promote)
+	channelFlag := preCmd.Flag("channel", "Pre-release channel in order (alpha, beta and rc by default). Identifiers in an earlier channel than the current version are rejected.").PlaceHolder("CHANNEL")
+	channelFlag.StringsVar(&channels)
 
 	var validateTag string
+	var verify bool
+	validateCmd.Flag("verify", "Reject version tags without a valid signature.").BoolVar(&verify)
 	validateCmd.Arg("tag", "Tag to validate. If omitted, validates tags pointing at HEAD.").StringVar(&validateTag)
 
 	var build internal.BuildFlag
-	for _, c := range []*kingpin.CmdClause{majorCmd, minorCmd, patchCmd, preCmd, releaseCmd} {
+	for _, c := range []*kingpin.CmdClause{majorCmd, minorCmd, patchCmd, preCmd, releaseCmd, autoCmd} {
 		c.Flag("build", "Update build notation. It accepts only alphanumeric or numeric identities.").SetValue(&build)
 	}
 	buildCmd.Arg("build", "Update build notation. It accepts only alphanumeric or numeric identities.").Required().SetValue(&build)
 
+	var rules internal.BumpRules
+	var noTag bool
+	autoCmd.Flag("major-type", "Commit type which bumps the major version. Breaking changes always bump it.").PlaceHolder("TYPE").StringsVar(&rules.Major)
+	autoCmd.Flag("minor-type", "Commit type which bumps the minor version.").PlaceHolder("TYPE").Default(internal.DefaultBumpRules().Minor...).StringsVar(&rules.Minor)
+	autoCmd.Flag("patch-type", "Commit type which bumps the patch version.").PlaceHolder("TYPE").Default(internal.DefaultBumpRules().Patch...).StringsVar(&rules.Patch)
+	autoCmd.Flag("no-tag", "Print the decided bump level without creating a tag.").BoolVar(&noTag)
+
+	var deleteTags []string
+	var deleteRange internal.RangeFlag
+	var yes bool
+	deleteCmd.Flag("range", "Delete versions in the range (e.g. \">=1.3.0-0 <1.3.0\" for pre-releases of v1.3.0).").PlaceHolder("RANGE").SetValue(&deleteRange)
+	deleteCmd.Flag("yes", "Delete without the confirmation.").Short('y').BoolVar(&yes)
+	deleteCmd.Arg("tags", "Tags to delete. If neither tags nor --range is given, the current version tag is deleted.").StringsVar(&deleteTags)
+
+	var constraint, satisfiesTag string
+	satisfiesCmd.Arg("constraint", "Range of versions (e.g. \">=2.3.0 <3\").").Required().StringVar(&constraint)
+	satisfiesCmd.Arg("tag", "Tag to check. If omitted, the current version tag is checked.").StringVar(&satisfiesTag)
+
+	var listRange internal.RangeFlag
+	var listOpts internal.ListOptions
+	listCmd.Flag("range", "List only versions in the range (e.g. \">=1.2.0 <2.0.0\").").PlaceHolder("RANGE").SetValue(&listRange)
+	listCmd.Flag("stable-only", "List only versions without pre-release notation.").BoolVar(&listOpts.StableOnly)
+	listCmd.Flag("pre-only", "List only pre-release versions.").BoolVar(&listOpts.PreOnly)
+	listCmd.Flag("latest-per", "List only the latest version for each major, minor or patch version.").EnumVar(&listOpts.LatestPer, "major", "minor", "patch")
+	listCmd.Flag("limit", "List only the latest N versions.").PlaceHolder("N").IntVar(&listOpts.Limit)
+
+	var changelogFrom, changelogTo, changelogFormat string
+	changelogCmd.Flag("from", "Tag to start from (exclusive). If omitted, the previous version of --to is used. Unlike --from of other commands, it does not limit tags to the reachable ones: use --reachable for it.").PlaceHolder("TAG").StringVar(&changelogFrom)
+	changelogCmd.Flag("to", "Tag to end with (inclusive). If omitted, the current version tag is used.").PlaceHolder("TAG").StringVar(&changelogTo)
+	changelogCmd.Flag("format", "Output format (markdown or json). If omitted, it follows --output: json for json, or markdown.").EnumVar(&changelogFormat, "markdown", "json")
+
+	// Configurations take precedence over built-in defaults, and are overridden by flags and envars.
+	preBackend := preParseFlag(app, os.Args[1:], "backend")
+	if preBackend == "" {
+		preBackend = os.Getenv("GIT_VERTAG_BACKEND")
+	}
+	cfg, err := internal.LoadConfig(&internal.Tagger{
+		Runner:  newRunner(preBackend),
+		Workdir: preParseFlag(app, os.Args[1:], "current-directory"),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Prefix != nil {
+		prefixFlag.Default(*cfg.Prefix)
+	}
+	if cfg.Pattern != nil {
+		patternFlag.Default(*cfg.Pattern)
+	}
+	if cfg.CalVer != nil {
+		calverFlag.Default(*cfg.CalVer)
+	}
+	if cfg.Scheme != nil {
+		schemeFlag.Default(*cfg.Scheme)
+	}
+	if cfg.Fetch != nil {
+		fetchFlag.Default(strconv.FormatBool(*cfg.Fetch))
+	}
+	fetchFromFlag.Default(cfg.FetchFrom...)
+	if cfg.PruneTags != nil {
+		pruneTagsFlag.Default(strconv.FormatBool(*cfg.PruneTags))
+	}
+	if cfg.Ancestors != nil {
+		ancestorsFlag.Default(strconv.FormatBool(*cfg.Ancestors))
+	}
+	for _, f := range pushToFlags {
+		if cfg.PushTo != nil {
+			f.Default(*cfg.PushTo)
+		}
+	}
+	for _, f := range messageTemplateFlags {
+		if cfg.MessageTemplate != nil {
+			f.Default(*cfg.MessageTemplate)
+		}
+	}
+	for _, f := range preFlags {
+		f.Default(cfg.Pre...)
+	}
+	channelFlag.Default(cfg.Channels...)
+
 	cmd, err := app.Parse(os.Args[1:])
 	if err != nil {
 		app.FatalUsage("%s", err)
 	}
 
-	runner := internal.NewGitRunner()
+	runner := newRunner(backend)
 	if dryRun {
-		runner = internal.NewDryRunner()
+		runner = internal.NewDryRunner(runner)
+	}
+	if len(message) == 0 && file == "" {
+		message = cfg.Message
+	}
+
+	if reachable && from == "" {
+		from = "HEAD"
+	}
+	if remoteOnly {
+		switch {
+		case from != "":
+			app.FatalUsage("--remote-only cannot be used with --reachable or --from")
+		case verify:
+			app.FatalUsage("--remote-only cannot be used with --verify")
+		}
+	} else {
+		remote = ""
+	}
+	if listOpts.StableOnly && listOpts.PreOnly {
+		app.FatalUsage("--stable-only cannot be used with --pre-only")
+	}
+	if promote && len(pre) > 0 {
+		app.FatalUsage("--promote cannot be used with a pre-release notation")
 	}
 	tag := internal.Tagger{
-		Runner:  runner,
-		Workdir: cwd,
-		PushTo:  pushTo,
+		Runner:       runner,
+		Workdir:      cwd,
+		PushTo:       pushTo,
+		Merged:       from,
+		LocalUser:    localUser,
+		RemoteOnly:   remote,
+		FetchFrom:    fetchFrom,
+		PruneTags:    pruneTags,
+		FetchTimeout: fetchTimeout,
+	}
+	if isOneOf(cmd, deleteCmd, majorCmd, minorCmd, patchCmd, preCmd, buildCmd, postCmd, devCmd, releaseCmd, autoCmd) {
+		tag.Sign = sign || tag.GetConfigBool("tag.gpgSign")
+	}
+
+	scheme := internal.Schemes[schemeName]
+	if calver != "" {
+		if schemeName != "semver" {
+			app.FatalUsage("--calver cannot be used with --scheme")
+		}
+		scheme, err = internal.ParseCalVer(calver)
+		if err != nil {
+			app.FatalUsage("%s", err)
+		}
+	}
+	semverTags := calver == "" && schemeName == "semver"
+	if moduleDir != "" && !semverTags {
+		app.FatalUsage("--module cannot be used with --calver or --scheme: Go modules need SemVer tags")
+	}
+
+	var mod *internal.GoModule
+	var modPrefix string
+	if moduleDir != "" {
+		if !filepath.IsAbs(moduleDir) {
+			moduleDir = filepath.Join(cwd, moduleDir)
+		}
+		mod, err = internal.FindGoModule(moduleDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		top, err := tag.GetTopLevel()
+		if err != nil {
+			log.Fatalf("failed to get the repository root: %s", err)
+		}
+		modPrefix, err = mod.TagPrefix(top, "")
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else if semverTags && prefix == "v" && pattern == "" && isOneOf(cmd, majorCmd, minorCmd, patchCmd, preCmd, buildCmd, postCmd, devCmd, releaseCmd, autoCmd) {
+		// guard the root module of the repository, for tags in the Go style (e.g. v1.2.3)
+		if top, err := tag.GetTopLevel(); err == nil {
+			if _, err := os.Stat(filepath.Join(top, "go.mod")); err == nil {
+				mod, err = internal.FindGoModule(top)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+	}
+
+	var suffix string
+	if pattern != "" {
+		prefix, suffix, err = internal.ParseTagPattern(pattern, strings.TrimSuffix(modPrefix, "/"))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if !strings.Contains(pattern, internal.PatternModule) {
+		prefix = modPrefix + prefix
+	}
+
+	var hooks *internal.Hooks
+	if !noHooks && isOneOf(cmd, deleteCmd, majorCmd, minorCmd, patchCmd, preCmd, buildCmd, postCmd, devCmd, releaseCmd, autoCmd) {
+		hooks = &internal.Hooks{Commands: cfg.Hooks, Workdir: cwd, DryRun: dryRun}
+		if top, err := tag.GetTopLevel(); err == nil {
+			hooks.Dir = filepath.Join(top, internal.HookDir)
+			hooks.Workdir = top
+		}
+	}
+
+	var files []internal.VersionFile
+	if writeFiles && !dryRun {
+		if len(cfg.Files) == 0 {
+			log.Fatalf("no files to write: set files in %s", internal.ConfigFile)
+		}
+		top, err := tag.GetTopLevel()
+		if err != nil {
+			log.Fatalf("failed to get the repository root: %s", err)
+		}
+		for _, f := range cfg.Files {
+			if !filepath.IsAbs(f.Path) {
+				f.Path = filepath.Join(top, f.Path)
+			}
+			files = append(files, f)
+		}
 	}
 
 	mgr := internal.Manager{
-		Prefix:    prefix,
-		Tagger:    tag,
-		Fetch:     fetch,
-		Ancestors: ancestors,
+		Prefix:          prefix,
+		Suffix:          suffix,
+		Scheme:          scheme,
+		Channels:        channels,
+		Tagger:          tag,
+		Fetch:           fetch,
+		Ancestors:       ancestors,
+		Module:          mod,
+		RewriteModule:   rewriteModule && !dryRun,
+		Verify:          verify,
+		MessageTemplate: messageTemplate,
+		Hooks:           hooks,
+		Files:           files,
+		Retry:           retry,
 	}
 
+	p := &printer{json: output == "json", tagger: &tag}
+	printResult := p.printResult
+
 	switch cmd {
 	case getCmd.FullCommand():
 		v, err := mgr.GetVer()
 		if err != nil {
 			return
 		}
-		fmt.Println(v)
+		p.printTag(mgr.Describe(v))
 
 	case validateCmd.FullCommand():
 		v, err := mgr.ValidateVer(validateTag)
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Println(v)
+		p.printTag(mgr.Describe(v))
 
 	case deleteCmd.FullCommand():
-		v, err := mgr.DeleteVer()
+		plan, err := mgr.PlanDelete(internal.DeleteOptions{Tags: deleteTags, Range: deleteRange.Range()})
 		if err != nil {
-			return
+			log.Fatal(err)
+		}
+		if err := plan.WriteSummary(os.Stderr, pushTo); err != nil {
+			log.Fatal(err)
+		}
+		if !yes && !dryRun && !confirm("Delete them?") {
+			log.Fatal("canceled")
+		}
+		res, err := mgr.Delete(plan, message, file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printWarnings(res)
+		if p.json {
+			p.printJSON(res, res.Next)
+		} else {
+			fmt.Println(res.Next)
 		}
-		fmt.Println(v)
 
 	case majorCmd.FullCommand():
 		printResult(mgr.UpdateMajor(pre, build, message, file))
@@ -123,6 +446,10 @@ func main() {
 		printResult(mgr.UpdatePatch(pre, build, message, file))
 
 	case preCmd.FullCommand():
+		if promote {
+			printResult(mgr.Promote(build, message, file))
+			break
+		}
 		printResult(mgr.UpdatePre(pre, build, message, file))
 
 	case releaseCmd.FullCommand():
@@ -130,12 +457,199 @@ func main() {
 
 	case buildCmd.FullCommand():
 		printResult(mgr.Build(build, message, file))
+
+	case postCmd.FullCommand():
+		printResult(mgr.UpdatePost(message, file))
+
+	case devCmd.FullCommand():
+		printResult(mgr.UpdateDev(message, file))
+
+	case satisfiesCmd.FullCommand():
+		c, err := internal.ParseConstraint(constraint)
+		if err != nil {
+			log.Print(err)
+			os.Exit(2)
+		}
+		s, err := mgr.Satisfies(c, satisfiesTag)
+		if s == nil {
+			log.Print(err)
+			os.Exit(2)
+		}
+		if p.json {
+			if err := json.NewEncoder(os.Stdout).Encode(s); err != nil {
+				log.Print(err)
+				os.Exit(2)
+			}
+		} else if err == nil {
+			fmt.Printf("%s satisfies %s\n", s.Tag, s.Constraint)
+		} else {
+			fmt.Printf("%s does not satisfy %s\n", s.Tag, s.Constraint)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+
+	case listCmd.FullCommand():
+		listOpts.Range = listRange.Range()
+		tags, err := mgr.List(listOpts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p.printTags(mgr, tags)
+
+	case changelogCmd.FullCommand():
+		notes, err := mgr.Changelog(changelogFrom, changelogTo)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if changelogFormat == "json" || (changelogFormat == "" && p.json) {
+			err = notes.WriteJSON(os.Stdout)
+		} else {
+			err = notes.WriteMarkdown(os.Stdout)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	case autoCmd.FullCommand():
+		if noTag {
+			bump, err := mgr.DecideBump(rules)
+			if err != nil {
+				log.Fatal(err)
+			}
+			p.printBump(bump)
+			return
+		}
+		printResult(mgr.Auto(rules, pre, build, message, file))
+	}
+}
+
+func newRunner(backend string) internal.Runner {
+	if backend == "go-git" {
+		return internal.NewGoGitRunner()
+	}
+	return internal.NewGitRunner()
+}
+
+// preParseFlag finds a flag value before parsing the arguments to load configurations.
+func preParseFlag(app *kingpin.Application, args []string, name string) string {
+	ctx, err := app.ParseContext(args)
+	if err != nil {
+		return ""
+	}
+	for _, e := range ctx.Elements {
+		if f, ok := e.Clause.(*kingpin.FlagClause); ok && f.Model().Name == name && e.Value != nil {
+			return *e.Value
+		}
+	}
+	return ""
+}
+
+// confirm asks the question in the terminal. It is true without asking if the standard input is not a terminal.
+func confirm(question string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func isOneOf(cmd string, clauses ...*kingpin.CmdClause) bool {
+	for _, c := range clauses {
+		if c.FullCommand() == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+type printer struct {
+	json   bool
+	tagger *internal.Tagger
+}
+
+// printResult prints the result of a command which creates a tag.
+func (p *printer) printResult(res *internal.Result, err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+	printWarnings(res)
+	if p.json {
+		p.printJSON(res, "HEAD")
+		return
+	}
+	fmt.Printf("update %s to %s\n", res.Previous, res.Next)
+	for _, tag := range res.Moved {
+		fmt.Printf("move %s to %s\n", tag, res.Next)
+	}
+}
+
+// printBump prints the bump level decided by "auto --no-tag".
+func (p *printer) printBump(bump internal.Bump) {
+	if p.json {
+		res := &internal.Result{Bump: bump.String()}
+		if err := res.WriteJSON(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	fmt.Println(bump)
+}
+
+// printWarnings prints the failures which did not undo the result.
+func printWarnings(res *internal.Result) {
+	for _, w := range res.Warnings {
+		log.Printf("warning: %s", w)
+	}
+}
+
+func (p *printer) printTag(res *internal.Result) {
+	if p.json {
+		if commits, err := p.tagger.GetTagCommits(res.Tag); err == nil {
+			res.Commit = commits[res.Tag]
+		}
+		if err := res.WriteJSON(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
+	fmt.Println(res.Tag)
 }
 
-func printResult(cur, next string, err error) {
+// printTags prints tags in lines, or a JSON array of them.
+func (p *printer) printTags(mgr internal.Manager, tags []string) {
+	if !p.json {
+		for _, tag := range tags {
+			fmt.Println(tag)
+		}
+		return
+	}
+	commits, err := p.tagger.GetTagCommits(tags...)
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Printf("update %s to %s\n", cur, next)
+	results := make([]*internal.Result, 0, len(tags))
+	for _, tag := range tags {
+		res := mgr.Describe(tag)
+		res.Commit = commits[tag]
+		results = append(results, res)
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(results); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printJSON prints the result with the commit which the rev points at.
+func (p *printer) printJSON(res *internal.Result, rev string) {
+	if commit, err := p.tagger.GetCommit(rev); err == nil {
+		res.Commit = commit
+	}
+	if err := res.WriteJSON(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
 }